@@ -0,0 +1,117 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/io/event"
+	"gioui.org/io/key"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/unit"
+	"gioui.org/widget/material"
+
+	"loov.dev/traceview/trace"
+)
+
+// Brush tracks selecting a time range with Shift+drag to zoom into.
+type Brush struct {
+	rulerTag bool
+	spansTag bool
+
+	active   bool
+	from, to trace.Time
+}
+
+// starts reports whether the press starts selecting instead of clicking.
+func (brush *Brush) starts(e pointer.Event) bool {
+	return e.Modifiers.Contain(key.ModShift) && e.Buttons.Contain(pointer.ButtonPrimary)
+}
+
+// Range returns the selected time range.
+func (brush *Brush) Range() trace.TimeRange {
+	return trace.TimeRange{Start: min(brush.from, brush.to), Finish: max(brush.from, brush.to)}
+}
+
+// handleBrush handles Shift+drag in the area registered for tag,
+// on release zooms to the selected range.
+func (view *TimelineView) handleBrush(gtx layout.Context, tag *bool, width int) {
+	ui := view.UI
+	brush := &ui.Viewport.Brush
+	timeAt := func(x float32) trace.Time {
+		return view.ZoomStart + trace.Time(float64(x)/float64(width)*float64(view.ZoomFinish-view.ZoomStart))
+	}
+
+	event.Op(gtx.Ops, tag)
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: tag,
+			Kinds:  pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel,
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case pointer.Press:
+			if !brush.starts(e) || ui.Viewport.Pan.starts(e) {
+				continue
+			}
+			brush.active = true
+			brush.from = timeAt(e.Position.X)
+			brush.to = brush.from
+			gtx.Execute(pointer.GrabCmd{Tag: tag, ID: e.PointerID})
+		case pointer.Drag:
+			if brush.active {
+				brush.to = timeAt(e.Position.X)
+			}
+		case pointer.Release:
+			if !brush.active {
+				continue
+			}
+			brush.active = false
+			brush.to = timeAt(e.Position.X)
+			if r := brush.Range(); r.Duration() > 0 {
+				ui.ZoomAround(r.Start, 0, r.Duration())
+				view.zoomChanged(gtx)
+			}
+		case pointer.Cancel:
+			brush.active = false
+		}
+	}
+}
+
+// drawBrush highlights the range being selected, optionally
+// with the selected duration.
+func (view *TimelineView) drawBrush(gtx layout.Context, size image.Point, caption bool) {
+	brush := &view.UI.Viewport.Brush
+	if !brush.active {
+		return
+	}
+
+	r := brush.Range()
+	durationToPx := float64(size.X) / float64(view.ZoomFinish-view.ZoomStart)
+	x0 := int(durationToPx * float64(r.Start-view.ZoomStart))
+	x1 := max(int(durationToPx*float64(r.Finish-view.ZoomStart)), x0+1)
+	bounds := clip.Rect{
+		Min: image.Point{X: x0, Y: 0},
+		Max: image.Point{X: x1, Y: size.Y},
+	}
+	paint.FillShape(gtx.Ops, color.NRGBA{R: 0x80, G: 0xB0, B: 0xFF, A: 0x30}, bounds.Op())
+	edge := color.NRGBA{R: 0x80, G: 0xB0, B: 0xFF, A: 0xC0}
+	paint.FillShape(gtx.Ops, edge, clip.Rect{Min: bounds.Min, Max: image.Point{X: x0 + 1, Y: size.Y}}.Op())
+	paint.FillShape(gtx.Ops, edge, clip.Rect{Min: image.Point{X: x1 - 1, Y: 0}, Max: bounds.Max}.Op())
+
+	if caption {
+		defer op.Offset(image.Point{X: x0 + 4, Y: 4}).Push(gtx.Ops).Pop()
+		lbl := material.Label(view.Theme, unit.Sp(12), formatDuration(r.Duration().Std()))
+		lbl.Color = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+		lbl.Layout(gtx)
+	}
+}
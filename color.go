@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"hash/fnv"
 	"image/color"
 	"math"
 	"time"
+
+	"loov.dev/traceview/trace"
 )
 
 var tickIntervals = []time.Duration{
@@ -35,6 +38,9 @@ var tickIntervals = []time.Duration{
 	10 * time.Minute,
 }
 
+// matchColor highlights spans matching the search query.
+var matchColor = color.NRGBA{R: 0xFF, G: 0xD8, B: 0x40, A: 0xFF}
+
 func brighten(c color.NRGBA) color.NRGBA {
 	return color.NRGBA{
 		R: byte(min(int(c.R)+40, 255)),
@@ -44,12 +50,45 @@ func brighten(c color.NRGBA) color.NRGBA {
 	}
 }
 
-func spanColor(spanID, traceID int64) color.NRGBA {
-	p := spanID ^ traceID
+func dim(c color.NRGBA) color.NRGBA {
+	return color.NRGBA{R: c.R / 3, G: c.G / 3, B: c.B / 3, A: c.A}
+}
+
+// spanColor picks the hue based on the service, so that the spans of the
+// same service have the same color. Spans without a service are colored
+// by their ID. Failed spans are highlighted with a saturated red.
+func spanColor(span *trace.Span) color.NRGBA {
+	if span.Status.IsError() {
+		return hslColor(0, 0.7, 0.35)
+	}
+
+	var p uint64
+	if span.Service != "" {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(span.Service))
+		p = h.Sum64()
+	} else {
+		p = uint64(span.SpanID) ^ span.TraceID.High ^ span.TraceID.Low
+	}
 	hue := float64(uint16(p)) / 0xFFFF * 360.0
 	return hslColor(hue, 0.4, 0.3)
 }
 
+// selfTimeColor colors spans from blue to red based on the share
+// of the span duration not covered by children.
+func selfTimeColor(share float64) color.NRGBA {
+	share = max(0, min(share, 1))
+	return hslColor(220*(1-share), 0.5, 0.3+0.1*share)
+}
+
+// selfTimeShare returns the share of self time in the span duration.
+func selfTimeShare(span *trace.Span, self trace.Time) float64 {
+	if span.Duration() <= 0 {
+		return 1
+	}
+	return float64(self) / float64(span.Duration())
+}
+
 func hslColor(h, s, l float64) color.NRGBA {
 	c := (1 - math.Abs(2*l-1)) * s
 	h2 := h / 60.0
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+
+	"loov.dev/traceview/trace"
+)
+
+// CriticalPath is the critical path of all traces, indexed by span.
+type CriticalPath map[*trace.Span][]trace.TimeRange
+
+// NewCriticalPath computes the critical path of every trace in timeline.
+func NewCriticalPath(timeline *trace.Timeline) CriticalPath {
+	path := make(CriticalPath)
+	for _, tr := range timeline.Traces {
+		for _, segment := range tr.CriticalPath() {
+			path[segment.Span] = append(path[segment.Span], segment.TimeRange)
+		}
+	}
+	return path
+}
+
+// criticalPath returns the critical path when highlighting is enabled.
+func (ui *UI) criticalPath() CriticalPath {
+	if !ui.ShowCriticalPath.Value {
+		return nil
+	}
+	if ui.critical == nil {
+		ui.critical = NewCriticalPath(ui.Timeline)
+	}
+	return ui.critical
+}
+
+// drawCriticalPath highlights the critical segments of span within bounds.
+func (view *TimelineView) drawCriticalPath(gtx layout.Context, span *trace.Span, bounds clip.Rect, durationToPx float64) {
+	segments := view.Critical[span]
+	if len(segments) == 0 {
+		return
+	}
+
+	highlight := color.NRGBA{R: 0xFF, G: 0xB0, B: 0x30, A: 0xFF}
+	height := max((bounds.Max.Y-bounds.Min.Y)/4, 2)
+	for _, segment := range segments {
+		x0 := int(durationToPx * float64(segment.Start-view.ZoomStart))
+		x1 := int(durationToPx * float64(segment.Finish-view.ZoomStart))
+		paint.FillShape(gtx.Ops, highlight, clip.Rect{
+			Min: image.Point{X: x0, Y: bounds.Max.Y - height},
+			Max: image.Point{X: max(x1, x0+1), Y: bounds.Max.Y},
+		}.Op())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"strings"
 
 	"gioui.org/layout"
 	"gioui.org/op/clip"
@@ -20,14 +21,21 @@ import (
 type DetailPanel struct {
 	Span *trace.Span
 
-	TagScroll widget.List
-	LogScroll widget.List
+	TagScroll     widget.List
+	ProcessScroll widget.List
+	LogScroll     widget.List
+
+	// tags and processTags are the sorted tags of sortedSpan.
+	sortedSpan  *trace.Span
+	tags        []trace.Tag
+	processTags []trace.Tag
 }
 
 func NewDetailPanel() DetailPanel {
 	return DetailPanel{
-		TagScroll: widget.List{List: layout.List{Axis: layout.Vertical}},
-		LogScroll: widget.List{List: layout.List{Axis: layout.Vertical}},
+		TagScroll:     widget.List{List: layout.List{Axis: layout.Vertical}},
+		ProcessScroll: widget.List{List: layout.List{Axis: layout.Vertical}},
+		LogScroll:     widget.List{List: layout.List{Axis: layout.Vertical}},
 	}
 }
 
@@ -46,6 +54,14 @@ func (d *DetailPanel) Layout(gtx layout.Context, th *material.Theme) layout.Dime
 	gtx.Constraints.Max.Y = height
 
 	span := d.Span
+	if d.sortedSpan != span {
+		d.sortedSpan = span
+		d.tags = trace.SortedTags(span.Tags)
+		d.processTags = nil
+		if span.Process != nil {
+			d.processTags = trace.SortedTags(span.Process.Tags)
+		}
+	}
 
 	return layout.Stack{}.Layout(gtx,
 		layout.Expanded(func(gtx layout.Context) layout.Dimensions {
@@ -67,17 +83,25 @@ func (d *DetailPanel) Layout(gtx layout.Context, th *material.Theme) layout.Dime
 							layout.Rigid(layout.Spacer{Height: unit.Dp(2)}.Layout),
 							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 								dur := formatDuration(span.Duration().Std())
-								info := fmt.Sprintf("Duration: %s  |  Children: %d  |  Parents: %d",
-									dur, len(span.Children), len(span.Parents))
+								self := span.SelfTime()
+								info := fmt.Sprintf("Duration: %s  |  Self: %s (%.0f%%)  |  Children: %d  |  Parents: %d",
+									dur, formatDuration(self.Std()), 100*selfTimeShare(span, self), len(span.Children), len(span.Parents))
 								lbl := material.Caption(th, info)
 								lbl.Color = color.NRGBA{R: 0xA0, G: 0xA0, B: 0xA8, A: 0xFF}
 								return lbl.Layout(gtx)
 							}),
+							layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+								return d.layoutService(gtx, th, span)
+							}),
 						)
 					}),
 					layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
 					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-						return d.layoutTags(gtx, th, span.Tags)
+						return d.layoutTags(gtx, th, d.tags)
+					}),
+					layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						return d.layoutProcess(gtx, th, span.Process, d.processTags)
 					}),
 					layout.Rigid(layout.Spacer{Width: unit.Dp(24)}.Layout),
 					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
@@ -89,22 +113,65 @@ func (d *DetailPanel) Layout(gtx layout.Context, th *material.Theme) layout.Dime
 	)
 }
 
+func (d *DetailPanel) layoutService(gtx layout.Context, th *material.Theme, span *trace.Span) layout.Dimensions {
+	var info []string
+	if span.Service != "" {
+		info = append(info, "Service: "+span.Service)
+	}
+	if span.Kind != trace.SpanKindUnspecified {
+		info = append(info, "Kind: "+span.Kind.String())
+	}
+	if span.Status.Code != trace.StatusUnset {
+		info = append(info, "Status: "+span.Status.String())
+	}
+	if span.Skew > 0 {
+		info = append(info, "Skew adjusted: +"+formatDuration(span.Skew.Std()))
+	} else if span.Skew < 0 {
+		info = append(info, "Skew adjusted: -"+formatDuration(-span.Skew.Std()))
+	}
+	if len(info) == 0 {
+		return layout.Dimensions{}
+	}
+
+	lbl := material.Caption(th, strings.Join(info, "  |  "))
+	lbl.Color = color.NRGBA{R: 0xA0, G: 0xA0, B: 0xA8, A: 0xFF}
+	if span.Status.IsError() {
+		lbl.Color = color.NRGBA{R: 0xFF, G: 0x70, B: 0x70, A: 0xFF}
+	}
+	lbl.MaxLines = 1
+	return lbl.Layout(gtx)
+}
+
 func (d *DetailPanel) layoutTags(gtx layout.Context, th *material.Theme, tags []trace.Tag) layout.Dimensions {
 	if len(tags) == 0 {
 		return layout.Dimensions{}
 	}
+	return d.layoutTagList(gtx, th, "Tags", &d.TagScroll, tags)
+}
+
+func (d *DetailPanel) layoutProcess(gtx layout.Context, th *material.Theme, proc *trace.Process, tags []trace.Tag) layout.Dimensions {
+	if proc == nil {
+		return layout.Dimensions{}
+	}
+	title := "Process"
+	if proc.Service != "" {
+		title += ": " + proc.Service
+	}
+	return d.layoutTagList(gtx, th, title, &d.ProcessScroll, tags)
+}
 
+func (d *DetailPanel) layoutTagList(gtx layout.Context, th *material.Theme, title string, scroll *widget.List, tags []trace.Tag) layout.Dimensions {
 	return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
 		layout.Rigid(func(gtx layout.Context) layout.Dimensions {
-			lbl := material.Caption(th, "Tags")
+			lbl := material.Caption(th, title)
 			lbl.Color = color.NRGBA{R: 0xB0, G: 0xB0, B: 0xB4, A: 0xFF}
 			return lbl.Layout(gtx)
 		}),
 		layout.Rigid(layout.Spacer{Height: tui.Tiny}.Layout),
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
-			return material.List(th, &d.TagScroll).Layout(gtx, len(tags), func(gtx layout.Context, i int) layout.Dimensions {
+			return material.List(th, scroll).Layout(gtx, len(tags), func(gtx layout.Context, i int) layout.Dimensions {
 				tag := tags[i]
-				lbl := material.Caption(th, tag.Key+": "+tag.Value)
+				lbl := material.Caption(th, tag.Key+": "+tag.Value.String())
 				lbl.Color = color.NRGBA{R: 0xCC, G: 0xCC, B: 0xCC, A: 0xFF}
 				lbl.MaxLines = 1
 				return lbl.Layout(gtx)
@@ -130,7 +197,7 @@ func (d *DetailPanel) layoutLogs(gtx layout.Context, th *material.Theme, logs []
 				log := logs[i]
 				text := formatDuration(log.Timestamp.Std())
 				for _, f := range log.Fields {
-					text += " " + f.Key + "=" + f.Value
+					text += " " + f.Key + "=" + f.Value.String()
 				}
 				lbl := material.Caption(th, text)
 				lbl.Color = color.NRGBA{R: 0xCC, G: 0xCC, B: 0xCC, A: 0xFF}
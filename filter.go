@@ -0,0 +1,77 @@
+package main
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/traceview/query"
+	"loov.dev/traceview/trace"
+	"loov.dev/traceview/tui"
+)
+
+// Filter hides the spans that don't match the query.
+type Filter struct {
+	Editor widget.Editor
+
+	Expr query.Expr
+	// Err is the parse error of the current query, the previous
+	// valid query stays in effect while there's an error.
+	Err error
+
+	query   string
+	applied bool
+}
+
+func NewFilter(initial string) Filter {
+	filter := Filter{Editor: widget.Editor{SingleLine: true}}
+	filter.Editor.SetText(initial)
+	return filter
+}
+
+// Update parses the query when it has changed and updates span visibility.
+func (filter *Filter) Update(timeline *trace.Timeline) {
+	text := filter.Editor.Text()
+	if filter.applied && text == filter.query {
+		return
+	}
+	filter.query = text
+
+	expr, err := query.Parse(text)
+	filter.Err = err
+	if err != nil {
+		if filter.applied {
+			return
+		}
+		expr = query.All{}
+	}
+	filter.Expr = expr
+	filter.applied = true
+
+	for _, tr := range timeline.Traces {
+		for _, span := range tr.Order {
+			span.Visible = expr.Match(span)
+		}
+	}
+}
+
+func (filter *Filter) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	return tui.Panel(th, "Filter").Layout(gtx,
+		tui.TextEditor(th, &filter.Editor, "duration>1ms AND NOT status=error").Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			if filter.Err == nil {
+				return layout.Dimensions{}
+			}
+			return errorLabel(th, filter.Err).Layout(gtx)
+		},
+	)
+}
+
+func errorLabel(th *material.Theme, err error) material.LabelStyle {
+	lbl := material.Body2(th, err.Error())
+	lbl.TextSize *= 0.8
+	lbl.Color = color.NRGBA{R: 0xFF, G: 0x60, B: 0x60, A: 0xFF}
+	return lbl
+}
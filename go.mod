@@ -1,10 +1,12 @@
 module loov.dev/traceview
 
-go 1.26
+go 1.26.0
 
 require (
 	gioui.org v0.9.1-0.20260317161059-dfe4ff020039
+	github.com/klauspost/compress v1.20.1
 	github.com/zeebo/clingy v0.0.0-20260119143559-4d23ffb0341b
+	golang.org/x/exp v0.0.0-20260908205506-85c1c2202aba
 )
 
 require (
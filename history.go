@@ -0,0 +1,203 @@
+package main
+
+import (
+	"image/color"
+	"time"
+
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/traceview/trace"
+	"loov.dev/traceview/tui"
+)
+
+const (
+	// historySettle is how long the view must stay unchanged before
+	// a view change is recorded as a new history entry.
+	historySettle = time.Second
+	// historyLimit is the maximum number of history entries.
+	historyLimit = 100
+	// breadcrumbLimit is the number of selections in the breadcrumb.
+	breadcrumbLimit = 6
+)
+
+// ViewState is a position in the navigation history.
+type ViewState struct {
+	Selected   *trace.Span
+	ZoomOffset trace.Time
+	ZoomLevel  time.Duration
+	ScrollY    int
+}
+
+// History records the navigation for going back and forward.
+//
+// Selecting a span always adds a new entry. Continuous view changes,
+// such as scrolling, update the current entry, unless the view has been
+// unchanged for a while.
+type History struct {
+	Entries []ViewState
+	Index   int
+
+	lastChange time.Time
+	// restored updates the current entry with the next observed state,
+	// since restoring may adjust the state, e.g. clamp the scroll.
+	restored bool
+
+	tag    bool
+	crumbs []widget.Clickable
+}
+
+func (ui *UI) viewState() ViewState {
+	return ViewState{
+		Selected:   ui.Selected,
+		ZoomOffset: ui.Viewport.ZoomOffset,
+		ZoomLevel:  ui.ZoomLevel.Value,
+		ScrollY:    ui.Viewport.ScrollY,
+	}
+}
+
+func (ui *UI) setViewState(state ViewState) {
+	ui.Selected = state.Selected
+	ui.Viewport.ZoomOffset = state.ZoomOffset
+	ui.ZoomLevel.SetValue(state.ZoomLevel)
+	ui.Viewport.ScrollY = state.ScrollY
+}
+
+// observe records the current state.
+func (history *History) observe(state ViewState, now time.Time) {
+	if len(history.Entries) == 0 {
+		history.Entries = append(history.Entries, state)
+		history.lastChange = now
+		return
+	}
+
+	current := &history.Entries[history.Index]
+	if *current == state {
+		return
+	}
+
+	switch {
+	case history.restored:
+		history.restored = false
+		*current = state
+	case current.Selected != state.Selected || now.Sub(history.lastChange) >= historySettle:
+		history.Entries = append(history.Entries[:history.Index+1], state)
+		if len(history.Entries) > historyLimit {
+			history.Entries = history.Entries[len(history.Entries)-historyLimit:]
+		}
+		history.Index = len(history.Entries) - 1
+	default:
+		*current = state
+	}
+	history.lastChange = now
+}
+
+// Go moves to the entry at index and restores the state.
+func (ui *UI) Go(index int) {
+	history := &ui.History
+	if index < 0 || index >= len(history.Entries) || index == history.Index {
+		return
+	}
+	history.Index = index
+	history.restored = true
+	ui.setViewState(history.Entries[index])
+}
+
+// Back restores the previous state.
+func (ui *UI) Back() { ui.Go(ui.History.Index - 1) }
+
+// Forward restores the next state, after going back.
+func (ui *UI) Forward() { ui.Go(ui.History.Index + 1) }
+
+// handleHistory handles the back and forward mouse buttons
+// in the current clip area.
+func (ui *UI) handleHistory(gtx layout.Context) {
+	history := &ui.History
+	event.Op(gtx.Ops, &history.tag)
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: &history.tag,
+			Kinds:  pointer.Press,
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch {
+		case e.Buttons.Contain(pointer.ButtonQuaternary):
+			ui.Back()
+		case e.Buttons.Contain(pointer.ButtonQuinary):
+			ui.Forward()
+		default:
+			continue
+		}
+		gtx.Execute(op.InvalidateCmd{})
+	}
+}
+
+// LayoutBreadcrumb shows the recent selections, clicking one
+// goes back to it.
+func (ui *UI) LayoutBreadcrumb(gtx layout.Context) layout.Dimensions {
+	history := &ui.History
+	th := ui.Theme
+
+	var indices []int
+	for i := len(history.Entries) - 1; i >= 0 && len(indices) < breadcrumbLimit; i-- {
+		selected := history.Entries[i].Selected
+		if selected == nil {
+			continue
+		}
+		if len(indices) > 0 && history.Entries[indices[len(indices)-1]].Selected == selected {
+			// Show the latest visit of the span.
+			continue
+		}
+		indices = append(indices, i)
+	}
+	if len(indices) == 0 {
+		return layout.Dimensions{}
+	}
+
+	if len(history.crumbs) < breadcrumbLimit {
+		history.crumbs = make([]widget.Clickable, breadcrumbLimit)
+	}
+
+	var children []layout.FlexChild
+	for k := len(indices) - 1; k >= 0; k-- {
+		index := indices[k]
+		crumb := &history.crumbs[k]
+		if crumb.Clicked(gtx) {
+			ui.Go(index)
+		}
+		if len(children) > 0 {
+			children = append(children, layout.Rigid(breadcrumbLabel(th, " › ", 0x90).Layout))
+		}
+		caption := history.Entries[index].Selected.Caption
+		brightness := byte(0xB0)
+		if index == history.Index {
+			brightness = 0xFF
+		}
+		children = append(children, layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			return crumb.Layout(gtx, breadcrumbLabel(th, caption, brightness).Layout)
+		}))
+	}
+
+	return tui.Box(color.NRGBA{R: 0x20, G: 0x20, B: 0x28, A: 0xFF}).Layout(gtx,
+		func(gtx layout.Context) layout.Dimensions {
+			return layout.Flex{Alignment: layout.Middle}.Layout(gtx, children...)
+		})
+}
+
+func breadcrumbLabel(th *material.Theme, text string, brightness byte) material.LabelStyle {
+	lbl := material.Label(th, unit.Sp(11), text)
+	lbl.MaxLines = 1
+	lbl.Color = color.NRGBA{R: brightness, G: brightness, B: brightness, A: 0xFF}
+	return lbl
+}
@@ -0,0 +1,460 @@
+package chrome
+
+import (
+	"fmt"
+	"sort"
+
+	"loov.dev/traceview/import/internal/hashid"
+	"loov.dev/traceview/trace"
+)
+
+// Convert converts trace events to a timeline.
+//
+// Each process becomes a trace and each thread becomes a span,
+// which contains the duration events of that thread. Counter, sample
+// and object events are ignored.
+func Convert(files ...File) (*trace.Timeline, error) {
+	conv := &converter{
+		spanByID:  make(map[trace.TraceSpanID]*trace.Span),
+		traceByID: make(map[trace.TraceID]*trace.Trace),
+	}
+	for _, file := range files {
+		conv.convert(file.TraceEvents)
+	}
+
+	timeline := &trace.Timeline{
+		SpanByID:  conv.spanByID,
+		Processes: conv.processes.List,
+		TimeRange: trace.InvalidRange,
+	}
+	for _, span := range conv.spans {
+		tr, ok := conv.traceByID[span.TraceID]
+		if !ok {
+			tr = &trace.Trace{
+				TraceID:   span.TraceID,
+				TimeRange: trace.InvalidRange,
+			}
+			timeline.Traces = append(timeline.Traces, tr)
+			conv.traceByID[span.TraceID] = tr
+		}
+		tr.Spans = append(tr.Spans, span)
+		tr.TimeRange = tr.TimeRange.Expand(span.TimeRange)
+		timeline.TimeRange = timeline.TimeRange.Expand(span.TimeRange)
+	}
+
+	timeline.Sort()
+	return timeline, nil
+}
+
+type converter struct {
+	spans     []*trace.Span
+	spanByID  map[trace.TraceSpanID]*trace.Span
+	traceByID map[trace.TraceID]*trace.Trace
+	processes trace.ProcessSet
+}
+
+type threadKey struct {
+	pid, tid ID
+}
+
+type thread struct {
+	threadKey
+	name string
+
+	open     []*slice
+	slices   []*slice
+	instants []*Event
+}
+
+// slice is a duration event on a thread.
+type slice struct {
+	span   *trace.Span
+	parent *slice
+}
+
+type asyncKey struct {
+	pid   ID
+	cat   string
+	id    ID
+	scope string
+}
+
+type flowKey struct {
+	cat string
+	id  ID
+}
+
+type flowPoint struct {
+	thread *thread
+	event  *Event
+}
+
+func (conv *converter) convert(events []Event) {
+	sort.SliceStable(events, func(i, k int) bool {
+		return events[i].Ts < events[k].Ts
+	})
+
+	processNames := make(map[ID]string)
+	threads := make(map[threadKey]*thread)
+	var threadOrder []*thread
+	ensureThread := func(ev *Event) *thread {
+		key := threadKey{pid: ev.Pid, tid: ev.Tid}
+		th, ok := threads[key]
+		if !ok {
+			th = &thread{threadKey: key}
+			threads[key] = th
+			threadOrder = append(threadOrder, th)
+		}
+		return th
+	}
+
+	async := make(map[asyncKey][]*trace.Span)
+	var flows []flowPoint
+	var last trace.Time
+
+	for i := range events {
+		ev := &events[i]
+		last = last.Max(ev.Ts.Time())
+
+		switch ev.Phase {
+		case Metadata:
+			name, _ := ev.Args["name"].(string)
+			switch ev.Name {
+			case "process_name":
+				processNames[ev.Pid] = name
+			case "thread_name":
+				ensureThread(ev).name = name
+			}
+
+		case DurationBegin:
+			th := ensureThread(ev)
+			th.open = append(th.open, &slice{span: conv.newSpan(ev, "slice")})
+
+		case DurationEnd:
+			th := ensureThread(ev)
+			if len(th.open) == 0 {
+				continue
+			}
+			s := th.open[len(th.open)-1]
+			th.open = th.open[:len(th.open)-1]
+			s.span.Finish = ev.Ts.Time()
+			s.span.Tags = append(s.span.Tags, convertArgs(ev.Args)...)
+			th.slices = append(th.slices, s)
+
+		case Complete:
+			th := ensureThread(ev)
+			s := &slice{span: conv.newSpan(ev, "slice")}
+			s.span.Finish = s.span.Start + ev.Dur.Time()
+			th.slices = append(th.slices, s)
+
+		case Instant, InstantLegacy:
+			if ev.S == "" || ev.S == "t" {
+				th := ensureThread(ev)
+				th.instants = append(th.instants, ev)
+				continue
+			}
+			conv.newSpan(ev, "instant")
+
+		case AsyncBegin, AsyncStart:
+			key := newAsyncKey(ev)
+			span := conv.newSpan(ev, "async")
+			span.Tags = append(span.Tags, trace.Tag{Key: "async.id", Value: trace.StringValue(string(key.id))})
+			if open := async[key]; len(open) > 0 {
+				parent := open[len(open)-1]
+				parent.Children = append(parent.Children, span)
+				span.Parents = append(span.Parents, parent)
+			}
+			async[key] = append(async[key], span)
+
+		case AsyncEnd, AsyncFinish:
+			key := newAsyncKey(ev)
+			open := async[key]
+			if len(open) == 0 {
+				continue
+			}
+			// Events are matched by name, falling back to the innermost.
+			at := len(open) - 1
+			for k := len(open) - 1; k >= 0; k-- {
+				if open[k].Caption == ev.Name {
+					at = k
+					break
+				}
+			}
+			span := open[at]
+			span.Finish = ev.Ts.Time()
+			span.Tags = append(span.Tags, convertArgs(ev.Args)...)
+			async[key] = append(open[:at], open[at+1:]...)
+
+		case AsyncInstant, AsyncStep:
+			open := async[newAsyncKey(ev)]
+			if len(open) == 0 {
+				continue
+			}
+			span := open[len(open)-1]
+			span.Logs = append(span.Logs, convertInstant(ev))
+
+		case FlowStart, FlowStep, FlowEnd:
+			flows = append(flows, flowPoint{thread: ensureThread(ev), event: ev})
+		}
+	}
+
+	// Close anything that didn't finish.
+	unfinished := trace.Tag{Key: "unfinished", Value: trace.BoolValue(true)}
+	for _, th := range threadOrder {
+		for k := len(th.open) - 1; k >= 0; k-- {
+			s := th.open[k]
+			s.span.Finish = last
+			s.span.Tags = append(s.span.Tags, unfinished)
+			th.slices = append(th.slices, s)
+		}
+		th.open = nil
+	}
+	for _, open := range async {
+		for _, span := range open {
+			span.Finish = last
+			span.Tags = append(span.Tags, unfinished)
+		}
+	}
+
+	for _, th := range threadOrder {
+		conv.nest(th)
+	}
+	conv.link(flows)
+
+	// Each named process is shown as a separate service.
+	pids := make([]ID, 0, len(processNames))
+	for pid := range processNames {
+		pids = append(pids, pid)
+	}
+	sort.Slice(pids, func(i, k int) bool { return pids[i] < pids[k] })
+
+	processes := make(map[trace.TraceID]*trace.Process, len(pids))
+	for _, pid := range pids {
+		processes[processTraceID(pid)] = conv.processes.Add(processNames[pid], []trace.Tag{
+			{Key: "pid", Value: trace.StringValue(string(pid))},
+		})
+	}
+	for _, span := range conv.spans {
+		if proc, ok := processes[span.TraceID]; ok {
+			span.Service = proc.Service
+			span.Process = proc
+		}
+	}
+}
+
+// nest builds the span hierarchy of a single thread.
+func (conv *converter) nest(th *thread) {
+	sort.SliceStable(th.slices, func(i, k int) bool {
+		a, b := th.slices[i].span, th.slices[k].span
+		if a.Start == b.Start {
+			return a.Finish > b.Finish
+		}
+		return a.Start < b.Start
+	})
+
+	var root *trace.Span
+	if len(th.slices) > 0 {
+		caption := th.name
+		if caption == "" {
+			caption = "thread " + string(th.tid)
+		}
+		root = conv.newSpan(&Event{Name: caption, Pid: th.pid, Tid: th.tid}, "thread")
+		root.TimeRange = trace.InvalidRange
+	}
+
+	instants := th.instants
+	var stack []*slice
+	for _, s := range th.slices {
+		// Attach instants that happened before this slice started.
+		for len(instants) > 0 && instants[0].Ts.Time() < s.span.Start {
+			stack = popFinished(stack, instants[0].Ts.Time())
+			conv.attachInstant(stack, instants[0])
+			instants = instants[1:]
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].span.Finish <= s.span.Start {
+			stack = stack[:len(stack)-1]
+		}
+
+		parent := root
+		if len(stack) > 0 {
+			s.parent = stack[len(stack)-1]
+			parent = s.parent.span
+		} else {
+			root.TimeRange = root.TimeRange.Expand(s.span.TimeRange)
+		}
+		parent.Children = append(parent.Children, s.span)
+		s.span.Parents = append(s.span.Parents, parent)
+
+		stack = append(stack, s)
+	}
+	for _, ev := range instants {
+		stack = popFinished(stack, ev.Ts.Time())
+		conv.attachInstant(stack, ev)
+	}
+}
+
+// popFinished removes slices that finished before t.
+func popFinished(stack []*slice, t trace.Time) []*slice {
+	for len(stack) > 0 && stack[len(stack)-1].span.Finish < t {
+		stack = stack[:len(stack)-1]
+	}
+	return stack
+}
+
+// attachInstant adds the instant event as a log to the innermost slice,
+// or as a separate span when no slice is active.
+func (conv *converter) attachInstant(stack []*slice, ev *Event) {
+	if len(stack) > 0 {
+		span := stack[len(stack)-1].span
+		span.Logs = append(span.Logs, convertInstant(ev))
+		return
+	}
+	conv.newSpan(ev, "instant")
+}
+
+// link binds flow events to the enclosing slices and links them.
+func (conv *converter) link(flows []flowPoint) {
+	previous := make(map[flowKey]*trace.Span)
+	for _, flow := range flows {
+		ev := flow.event
+		key := flowKey{cat: ev.Cat, id: ev.ID}
+
+		var span *trace.Span
+		if ev.Phase == FlowEnd && ev.BP != "e" {
+			span = nextSlice(flow.thread, ev.Ts.Time())
+		} else {
+			span = enclosingSlice(flow.thread, ev.Ts.Time())
+		}
+		if span == nil {
+			continue
+		}
+
+		if prev, ok := previous[key]; ok && prev != span && ev.Phase != FlowStart {
+			prev.FollowedBy = append(prev.FollowedBy, span)
+			span.FollowsFrom = append(span.FollowsFrom, prev)
+		}
+
+		if ev.Phase == FlowEnd {
+			delete(previous, key)
+		} else {
+			previous[key] = span
+		}
+	}
+}
+
+// enclosingSlice finds the innermost slice containing t.
+func enclosingSlice(th *thread, t trace.Time) *trace.Span {
+	i := sort.Search(len(th.slices), func(i int) bool {
+		return th.slices[i].span.Start > t
+	})
+	if i == 0 {
+		return nil
+	}
+	for s := th.slices[i-1]; s != nil; s = s.parent {
+		if s.span.Start <= t && t <= s.span.Finish {
+			return s.span
+		}
+	}
+	return nil
+}
+
+// nextSlice finds the first slice starting at or after t,
+// falling back to the enclosing slice.
+func nextSlice(th *thread, t trace.Time) *trace.Span {
+	i := sort.Search(len(th.slices), func(i int) bool {
+		return th.slices[i].span.Start >= t
+	})
+	if i < len(th.slices) {
+		return th.slices[i].span
+	}
+	return enclosingSlice(th, t)
+}
+
+func newAsyncKey(ev *Event) asyncKey {
+	key := asyncKey{pid: ev.Pid, cat: ev.Cat, id: ev.ID, scope: ev.Scope}
+	if ev.ID2 != nil {
+		if ev.ID2.Global != "" {
+			key.pid = ""
+			key.id = ev.ID2.Global
+		} else {
+			key.id = ev.ID2.Local
+		}
+	}
+	return key
+}
+
+// processTraceID returns the trace containing the process.
+func processTraceID(pid ID) trace.TraceID {
+	return hashid.TraceID("pid", string(pid))
+}
+
+// newSpan creates a span for the event.
+//
+// The IDs are derived from the event content, so that the same
+// event loaded from multiple files ends up with the same ID.
+func (conv *converter) newSpan(ev *Event, kind string) *trace.Span {
+	id := trace.TraceSpanID{
+		TraceID: processTraceID(ev.Pid),
+		SpanID:  hashid.SpanID(kind, string(ev.Pid), string(ev.Tid), fmt.Sprint(float64(ev.Ts)), ev.Cat, ev.Name),
+	}
+	for id.SpanID.IsZero() || conv.spanByID[id] != nil {
+		id.SpanID++
+	}
+
+	span := &trace.Span{
+		TraceSpanID: id,
+		Caption:     ev.Name,
+		TimeRange: trace.TimeRange{
+			Start:  ev.Ts.Time(),
+			Finish: ev.Ts.Time(),
+		},
+	}
+	if ev.Cat != "" {
+		span.Tags = append(span.Tags, trace.Tag{Key: "category", Value: trace.StringValue(ev.Cat)})
+	}
+	if ev.Tid != "" {
+		span.Tags = append(span.Tags, trace.Tag{Key: "tid", Value: trace.StringValue(string(ev.Tid))})
+	}
+	span.Tags = append(span.Tags, convertArgs(ev.Args)...)
+
+	conv.spanByID[id] = span
+	conv.spans = append(conv.spans, span)
+	return span
+}
+
+func convertInstant(ev *Event) trace.Log {
+	fields := []trace.Tag{{Key: "event", Value: trace.StringValue(ev.Name)}}
+	fields = append(fields, convertArgs(ev.Args)...)
+	return trace.Log{
+		Timestamp: ev.Ts.Time(),
+		Fields:    fields,
+	}
+}
+
+// convertArgs converts args to tags, flattening nested objects.
+func convertArgs(args map[string]any) []trace.Tag {
+	if len(args) == 0 {
+		return nil
+	}
+	var tags []trace.Tag
+	var flatten func(prefix string, args map[string]any)
+	flatten = func(prefix string, args map[string]any) {
+		keys := make([]string, 0, len(args))
+		for key := range args {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			switch value := args[key].(type) {
+			case map[string]any:
+				flatten(prefix+key+".", value)
+			default:
+				tags = append(tags, trace.Tag{Key: prefix + key, Value: trace.AnyValue(value)})
+			}
+		}
+	}
+	flatten("", args)
+	return tags
+}
@@ -0,0 +1,109 @@
+package chrome
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"loov.dev/traceview/trace"
+)
+
+// File is a Chrome Trace Event Format file.
+//
+// See https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU
+type File struct {
+	TraceEvents     []Event `json:"traceEvents"`
+	DisplayTimeUnit string  `json:"displayTimeUnit,omitempty"`
+}
+
+// Parse parses a trace file. The array format may be missing
+// the closing bracket, as allowed by the spec.
+func Parse(data []byte) (File, error) {
+	var file File
+	err := json.Unmarshal(data, &file)
+	if err == nil {
+		return file, nil
+	}
+
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) && !bytes.HasSuffix(data, []byte("]")) {
+		fixed := bytes.TrimSuffix(data, []byte(","))
+		fixed = append(fixed[:len(fixed):len(fixed)], ']')
+		if json.Unmarshal(fixed, &file) == nil {
+			return file, nil
+		}
+	}
+	return File{}, err
+}
+
+// UnmarshalJSON accepts both the JSON Object Format and the JSON Array Format.
+func (file *File) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return json.Unmarshal(data, &file.TraceEvents)
+	}
+	type object File
+	return json.Unmarshal(data, (*object)(file))
+}
+
+type Event struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat,omitempty"`
+	Phase Phase          `json:"ph"`
+	Ts    Micros         `json:"ts"`
+	Dur   Micros         `json:"dur,omitempty"`
+	Pid   ID             `json:"pid"`
+	Tid   ID             `json:"tid"`
+	ID    ID             `json:"id,omitempty"`
+	ID2   *ID2           `json:"id2,omitempty"`
+	Scope string         `json:"scope,omitempty"`
+	S     string         `json:"s,omitempty"`
+	BP    string         `json:"bp,omitempty"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// Phase is the event type.
+type Phase string
+
+const (
+	DurationBegin = Phase("B")
+	DurationEnd   = Phase("E")
+	Complete      = Phase("X")
+	Instant       = Phase("i")
+	InstantLegacy = Phase("I")
+	AsyncBegin    = Phase("b")
+	AsyncInstant  = Phase("n")
+	AsyncEnd      = Phase("e")
+	AsyncStart    = Phase("S") // deprecated
+	AsyncStep     = Phase("T") // deprecated
+	AsyncFinish   = Phase("F") // deprecated
+	FlowStart     = Phase("s")
+	FlowStep      = Phase("t")
+	FlowEnd       = Phase("f")
+	Metadata      = Phase("M")
+)
+
+// Micros is a timestamp or duration in microseconds.
+type Micros float64
+
+func (m Micros) Std() time.Duration { return time.Duration(float64(m) * float64(time.Microsecond)) }
+func (m Micros) Time() trace.Time   { return trace.NewTime(m.Std()) }
+
+// ID is an identifier, which may be written as a JSON number or string.
+type ID string
+
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*id = ""
+		return nil
+	}
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		return json.Unmarshal(data, (*string)(id))
+	}
+	*id = ID(data)
+	return nil
+}
+
+type ID2 struct {
+	Local  ID `json:"local,omitempty"`
+	Global ID `json:"global,omitempty"`
+}
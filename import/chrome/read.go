@@ -0,0 +1,36 @@
+package chrome
+
+import (
+	"fmt"
+	"io"
+
+	"loov.dev/traceview/import/internal/sniff"
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of a trace event file.
+func Detect(head []byte) bool {
+	obj, ok := sniff.FirstObject(head)
+	if !ok {
+		return false
+	}
+	return obj.Arrays == 0 && obj.Has("traceEvents") ||
+		obj.Arrays == 1 && obj.Has("ph")
+}
+
+// Read reads and converts a trace event file.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	timeline, err := Convert(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+	return timeline, nil
+}
@@ -0,0 +1,298 @@
+// Package gotrace converts runtime/trace execution traces.
+package gotrace
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	exptrace "golang.org/x/exp/trace"
+
+	"loov.dev/traceview/import/internal/hashid"
+	"loov.dev/traceview/trace"
+)
+
+// Options configures the conversion.
+type Options struct {
+	// Goroutines includes the execution of each goroutine
+	// as a separate trace.
+	Goroutines bool
+}
+
+// Convert converts an execution trace to a timeline.
+//
+// Each task becomes a trace with the task as the root span, regions
+// become nested spans and logs are attached to the innermost region.
+// Child tasks are linked to their parent task with FollowsFrom.
+func Convert(r io.Reader, opts Options) (*trace.Timeline, error) {
+	reader, err := exptrace.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+
+	conv := &converter{
+		opts:       opts,
+		spanByID:   make(map[trace.TraceSpanID]*trace.Span),
+		tasks:      make(map[exptrace.TaskID]*trace.Span),
+		regions:    make(map[exptrace.GoID][]*trace.Span),
+		goroutines: make(map[exptrace.GoID]*goroutine),
+		ended:      make(map[*trace.Span]bool),
+	}
+
+	first := true
+	for {
+		ev, err := reader.ReadEvent()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event: %w", err)
+		}
+		if first {
+			conv.first = trace.Time(ev.Time())
+			first = false
+		}
+		conv.event(&ev)
+	}
+
+	return conv.finish(), nil
+}
+
+type converter struct {
+	opts Options
+
+	spans    []*trace.Span
+	spanByID map[trace.TraceSpanID]*trace.Span
+
+	tasks      map[exptrace.TaskID]*trace.Span
+	ended      map[*trace.Span]bool
+	regions    map[exptrace.GoID][]*trace.Span
+	goroutines map[exptrace.GoID]*goroutine
+
+	first, last trace.Time
+}
+
+type goroutine struct {
+	span    *trace.Span
+	running *trace.Span
+}
+
+func (conv *converter) event(ev *exptrace.Event) {
+	now := trace.Time(ev.Time())
+	conv.last = conv.last.Max(now)
+
+	switch ev.Kind() {
+	case exptrace.EventTaskBegin:
+		task := ev.Task()
+		span := conv.task(task.ID, now)
+		span.Caption = task.Type
+		span.Start = now
+		if task.Parent != exptrace.NoTask && task.Parent != exptrace.BackgroundTask {
+			parent := conv.task(task.Parent, now)
+			parent.FollowedBy = append(parent.FollowedBy, span)
+			span.FollowsFrom = append(span.FollowsFrom, parent)
+		}
+
+	case exptrace.EventTaskEnd:
+		task := ev.Task()
+		span := conv.task(task.ID, now)
+		span.Finish = now
+		conv.ended[span] = true
+		if span.Caption == "" {
+			span.Caption = task.Type
+		}
+
+	case exptrace.EventRegionBegin:
+		region := ev.Region()
+		gid := ev.Goroutine()
+
+		parent := conv.task(region.Task, now)
+		if open := conv.regions[gid]; len(open) > 0 && open[len(open)-1].TraceID == parent.TraceID {
+			parent = open[len(open)-1]
+		}
+
+		span := conv.newSpan(parent.TraceID, region.Type, now, "region", strconv.FormatInt(int64(gid), 10))
+		span.Finish = now
+		span.Tags = append(span.Tags, trace.Tag{Key: "goroutine", Value: trace.IntValue(int64(gid))})
+		parent.Children = append(parent.Children, span)
+		span.Parents = append(span.Parents, parent)
+
+		conv.regions[gid] = append(conv.regions[gid], span)
+
+	case exptrace.EventRegionEnd:
+		region := ev.Region()
+		gid := ev.Goroutine()
+
+		open := conv.regions[gid]
+		for k := len(open) - 1; k >= 0; k-- {
+			if open[k].Caption == region.Type {
+				open[k].Finish = now
+				conv.regions[gid] = open[:k]
+				break
+			}
+		}
+
+	case exptrace.EventLog:
+		log := ev.Log()
+		gid := ev.Goroutine()
+
+		span := conv.task(log.Task, now)
+		if open := conv.regions[gid]; len(open) > 0 && open[len(open)-1].TraceID == span.TraceID {
+			span = open[len(open)-1]
+		}
+		span.Logs = append(span.Logs, trace.Log{
+			Timestamp: now,
+			Fields: []trace.Tag{
+				{Key: "category", Value: trace.StringValue(log.Category)},
+				{Key: "message", Value: trace.StringValue(log.Message)},
+			},
+		})
+
+	case exptrace.EventStateTransition:
+		if !conv.opts.Goroutines {
+			return
+		}
+		st := ev.StateTransition()
+		if st.Resource.Kind != exptrace.ResourceGoroutine {
+			return
+		}
+		conv.transition(st, now)
+	}
+}
+
+// transition tracks when goroutines are running.
+func (conv *converter) transition(st exptrace.StateTransition, now trace.Time) {
+	gid := st.Resource.Goroutine()
+	from, to := st.Goroutine()
+
+	g, ok := conv.goroutines[gid]
+	if !ok {
+		id := strconv.FormatInt(int64(gid), 10)
+		g = &goroutine{
+			span: conv.newSpan(goroutinesTraceID, "G"+id, now, "goroutine", id),
+		}
+		g.span.Tags = append(g.span.Tags, trace.Tag{Key: "goroutine", Value: trace.IntValue(int64(gid))})
+		conv.goroutines[gid] = g
+	}
+	if from == exptrace.GoNotExist {
+		for frame := range st.Stack.Frames() {
+			g.span.Caption += " " + frame.Func
+			break
+		}
+	}
+	g.span.Finish = now
+
+	switch {
+	case !from.Executing() && to.Executing():
+		g.running = conv.newSpan(goroutinesTraceID, "running", now, "running", strconv.FormatInt(int64(gid), 10))
+		g.span.Children = append(g.span.Children, g.running)
+		g.running.Parents = append(g.running.Parents, g.span)
+	case from.Executing() && !to.Executing() && g.running != nil:
+		g.running.Finish = now
+		if st.Reason != "" {
+			g.running.Tags = append(g.running.Tags, trace.Tag{Key: "reason", Value: trace.StringValue(st.Reason)})
+		}
+		g.running = nil
+	}
+}
+
+// goroutinesTraceID is the trace containing goroutine execution.
+var goroutinesTraceID = hashid.TraceID("goroutines")
+
+// task returns the root span for the task.
+func (conv *converter) task(id exptrace.TaskID, now trace.Time) *trace.Span {
+	if span, ok := conv.tasks[id]; ok {
+		return span
+	}
+
+	caption := ""
+	if id == exptrace.BackgroundTask || id == exptrace.NoTask {
+		id = exptrace.BackgroundTask
+		caption = "background"
+		if span, ok := conv.tasks[id]; ok {
+			return span
+		}
+	}
+
+	traceID := hashid.TraceID("task", strconv.FormatUint(uint64(id), 10))
+	span := conv.newSpan(traceID, caption, conv.first, "task", strconv.FormatUint(uint64(id), 10))
+	span.Finish = now
+	span.Tags = append(span.Tags, trace.Tag{Key: "task", Value: trace.IntValue(int64(id))})
+	conv.tasks[id] = span
+	return span
+}
+
+// newSpan creates a new span with an ID derived from the content,
+// so that the same trace loaded multiple times ends up with the same IDs.
+func (conv *converter) newSpan(traceID trace.TraceID, caption string, start trace.Time, kind string, parts ...string) *trace.Span {
+	id := trace.TraceSpanID{
+		TraceID: traceID,
+		SpanID:  hashid.SpanID(append([]string{kind, strconv.FormatInt(int64(start), 10)}, parts...)...),
+	}
+	for id.SpanID.IsZero() || conv.spanByID[id] != nil {
+		id.SpanID++
+	}
+
+	span := &trace.Span{
+		TraceSpanID: id,
+		Caption:     caption,
+		TimeRange: trace.TimeRange{
+			Start:  start,
+			Finish: start,
+		},
+	}
+	conv.spanByID[id] = span
+	conv.spans = append(conv.spans, span)
+	return span
+}
+
+func (conv *converter) finish() *trace.Timeline {
+	unfinished := trace.Tag{Key: "unfinished", Value: trace.BoolValue(true)}
+
+	// Tasks and regions that did not end, last until the end of the trace.
+	for id, span := range conv.tasks {
+		if conv.ended[span] {
+			continue
+		}
+		span.Finish = conv.last
+		if id != exptrace.BackgroundTask {
+			span.Tags = append(span.Tags, unfinished)
+		}
+	}
+	for _, open := range conv.regions {
+		for _, span := range open {
+			span.Finish = conv.last
+			span.Tags = append(span.Tags, unfinished)
+		}
+	}
+	for _, g := range conv.goroutines {
+		if g.running != nil {
+			g.running.Finish = conv.last
+			g.running.Tags = append(g.running.Tags, unfinished)
+		}
+	}
+
+	timeline := &trace.Timeline{
+		SpanByID:  conv.spanByID,
+		TimeRange: trace.InvalidRange,
+	}
+	traceByID := make(map[trace.TraceID]*trace.Trace)
+	for _, span := range conv.spans {
+		tr, ok := traceByID[span.TraceID]
+		if !ok {
+			tr = &trace.Trace{
+				TraceID:   span.TraceID,
+				TimeRange: trace.InvalidRange,
+			}
+			timeline.Traces = append(timeline.Traces, tr)
+			traceByID[span.TraceID] = tr
+		}
+		tr.Spans = append(tr.Spans, span)
+		tr.TimeRange = tr.TimeRange.Expand(span.TimeRange)
+		timeline.TimeRange = timeline.TimeRange.Expand(span.TimeRange)
+	}
+
+	timeline.Sort()
+	return timeline
+}
@@ -0,0 +1,20 @@
+package gotrace
+
+import (
+	"bytes"
+	"io"
+
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of an execution trace,
+// which starts with a header such as "go 1.22 trace\x00\x00\x00".
+func Detect(head []byte) bool {
+	header, _, ok := bytes.Cut(head, []byte{0})
+	return ok && bytes.HasPrefix(header, []byte("go 1.")) && bytes.HasSuffix(header, []byte(" trace"))
+}
+
+// Read reads and converts an execution trace, including goroutine execution.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	return Convert(r, Options{Goroutines: true})
+}
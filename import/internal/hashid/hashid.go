@@ -0,0 +1,29 @@
+// Package hashid derives stable trace and span IDs from the content of
+// events, for formats that don't have IDs of their own.
+package hashid
+
+import (
+	"hash/fnv"
+
+	"loov.dev/traceview/trace"
+)
+
+// Sum returns the fnv64a hash of the parts, each terminated by a zero byte.
+func Sum(parts ...string) uint64 {
+	h := fnv.New64a()
+	for _, part := range parts {
+		_, _ = h.Write([]byte(part))
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// TraceID returns a trace ID derived from the parts.
+func TraceID(parts ...string) trace.TraceID {
+	return trace.TraceID{Low: Sum(parts...)}
+}
+
+// SpanID returns a span ID derived from the parts.
+func SpanID(parts ...string) trace.SpanID {
+	return trace.SpanID(Sum(parts...))
+}
@@ -0,0 +1,81 @@
+// Package jsonstream implements helpers for decoding large JSON
+// documents one value at a time.
+package jsonstream
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Object calls fn for each key of the next object in dec.
+// fn must consume the value of the key. A null is treated as an empty object.
+func Object(dec *json.Decoder, fn func(key string) error) error {
+	if ok, err := open(dec, '{'); !ok || err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("expected object key, got %v", tok)
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// Array calls fn for each element of the next array in dec.
+// fn must consume the element. A null is treated as an empty array.
+func Array(dec *json.Decoder, fn func() error) error {
+	if ok, err := open(dec, '['); !ok || err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// Skip skips the next value in dec.
+func Skip(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// open consumes the opening delimiter, it returns false for null.
+func open(dec *json.Decoder, delim json.Delim) (bool, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return false, err
+	}
+	if tok == nil {
+		return false, nil
+	}
+	if tok != delim {
+		return false, fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return true, nil
+}
@@ -0,0 +1,87 @@
+// Package sniff implements helpers for detecting file formats.
+package sniff
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// IsJSON reports whether head starts like a JSON object or array.
+func IsJSON(head []byte) bool {
+	head = bytes.TrimSpace(head)
+	return len(head) > 0 && (head[0] == '{' || head[0] == '[')
+}
+
+// Object describes the first JSON object found in head.
+type Object struct {
+	// Arrays is the number of arrays enclosing the object.
+	Arrays int
+	// Keys are the keys of the object that fit in head.
+	Keys []string
+}
+
+// Has reports whether the object has the key.
+func (obj Object) Has(key string) bool {
+	for _, k := range obj.Keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// FirstObject finds the first JSON object in head, which may be truncated.
+func FirstObject(head []byte) (Object, bool) {
+	var obj Object
+	if !IsJSON(head) {
+		return obj, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(head))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return obj, false
+		}
+		if tok == json.Delim('[') {
+			obj.Arrays++
+			continue
+		}
+		if tok == json.Delim('{') {
+			break
+		}
+		return obj, false
+	}
+
+	depth := 0
+	expectKey := true
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return obj, true
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+			if depth < 0 {
+				return obj, true
+			}
+			if depth == 0 {
+				expectKey = true
+			}
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		if expectKey {
+			if key, ok := tok.(string); ok {
+				obj.Keys = append(obj.Keys, key)
+			}
+		}
+		expectKey = !expectKey
+	}
+}
@@ -1,6 +1,8 @@
 package jaeger
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -15,12 +17,70 @@ func convertTags(tags []Tag) []trace.Tag {
 	for i, t := range tags {
 		out[i] = trace.Tag{
 			Key:   t.Key,
-			Value: fmt.Sprint(t.Value),
+			Value: convertValue(t),
 		}
 	}
 	return out
 }
 
+// convertValue converts the value according to the tag type.
+// Numbers may be decoded as float64, json.Number or string.
+func convertValue(t Tag) trace.Value {
+	switch t.Type {
+	case BoolTag:
+		switch v := t.Value.(type) {
+		case bool:
+			return trace.BoolValue(v)
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return trace.BoolValue(b)
+			}
+		}
+	case Int64Tag:
+		switch v := t.Value.(type) {
+		case float64:
+			return trace.IntValue(int64(v))
+		case json.Number:
+			if x, err := v.Int64(); err == nil {
+				return trace.IntValue(x)
+			}
+		case string:
+			if x, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return trace.IntValue(x)
+			}
+		}
+	case Float64Tag:
+		switch v := t.Value.(type) {
+		case float64:
+			return trace.FloatValue(v)
+		case json.Number:
+			if x, err := v.Float64(); err == nil {
+				return trace.FloatValue(x)
+			}
+		case string:
+			if x, err := strconv.ParseFloat(v, 64); err == nil {
+				return trace.FloatValue(x)
+			}
+		}
+	case BinaryTag:
+		if v, ok := t.Value.(string); ok {
+			if data, err := base64.StdEncoding.DecodeString(v); err == nil {
+				return trace.BytesValue(data)
+			}
+		}
+	}
+
+	if v, ok := t.Value.(json.Number); ok {
+		if x, err := v.Int64(); err == nil {
+			return trace.IntValue(x)
+		}
+		if x, err := v.Float64(); err == nil {
+			return trace.FloatValue(x)
+		}
+	}
+	return trace.AnyValue(t.Value)
+}
+
 func convertLogs(logs []Log) []trace.Log {
 	if len(logs) == 0 {
 		return nil
@@ -36,82 +96,107 @@ func convertLogs(logs []Log) []trace.Log {
 }
 
 func Convert(traces ...Trace) (*trace.Timeline, error) {
-	var timeline trace.Timeline
-
-	traceByID := make(map[trace.TraceID]*trace.Trace)
-
-	timeline.SpanByID = make(map[trace.TraceSpanID]*trace.Span)
-	timeline.TimeRange = trace.InvalidRange
-
-	// span may be nil
-	ensure := func(refid TraceSpanID, span *Span, processes map[ProcessID]Process) (*trace.Span, error) {
-		id, err := convertTraceSpanID(refid)
-		if err != nil {
-			return nil, err
-		}
-
-		node, ok := timeline.SpanByID[id]
-		if !ok {
-			node = &trace.Span{}
-			timeline.SpanByID[id] = node
-		}
-		updateSpanContent(node, id, span, processes)
-
-		if span != nil {
-			tr, ok := traceByID[id.TraceID]
-			if !ok {
-				tr = &trace.Trace{
-					TraceID:   id.TraceID,
-					TimeRange: trace.InvalidRange,
-				}
-				timeline.Traces = append(timeline.Traces, tr)
-				traceByID[id.TraceID] = tr
+	conv := newConverter()
+	for i := range traces {
+		trace := &traces[i]
+		for k := range trace.Spans {
+			if _, err := conv.add(&trace.Spans[k], trace.Processes); err != nil {
+				return nil, err
 			}
-			tr.Spans = append(tr.Spans, node)
-			tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
 		}
+	}
+	return conv.finish(), nil
+}
+
+// converter builds the timeline one span at a time.
+type converter struct {
+	timeline  trace.Timeline
+	traceByID map[trace.TraceID]*trace.Trace
+	processes trace.ProcessSet
+}
 
-		return node, nil
+func newConverter() *converter {
+	return &converter{
+		timeline: trace.Timeline{
+			SpanByID:  make(map[trace.TraceSpanID]*trace.Span),
+			TimeRange: trace.InvalidRange,
+		},
+		traceByID: make(map[trace.TraceID]*trace.Trace),
 	}
+}
 
-	for i := range traces {
-		trace := &traces[i]
-		for k := range trace.Spans {
-			span := &trace.Spans[k]
+// add converts span and links it to the references.
+func (conv *converter) add(span *Span, processes map[ProcessID]Process) (*trace.Span, error) {
+	node, err := conv.ensure(span.TraceSpanID, span)
+	if err != nil {
+		return nil, err
+	}
+	if proc, ok := processes[span.ProcessID]; ok {
+		conv.updateSpanProcess(node, proc)
+	}
 
-			node, err := ensure(span.TraceSpanID, span, trace.Processes)
+	conv.timeline.TimeRange = conv.timeline.TimeRange.Expand(node.TimeRange)
+
+	for _, ref := range span.References {
+		switch ref.RefType {
+		case ChildOf:
+			parent, err := conv.ensure(ref.TraceSpanID, nil)
+			if err != nil {
+				return nil, err
+			}
+			parent.Children = append(parent.Children, node)
+			node.Parents = append(node.Parents, parent)
+		case FollowsFrom:
+			parent, err := conv.ensure(ref.TraceSpanID, nil)
 			if err != nil {
 				return nil, err
 			}
+			parent.FollowedBy = append(parent.FollowedBy, node)
+			node.FollowsFrom = append(node.FollowsFrom, parent)
+		}
+	}
+
+	return node, nil
+}
+
+// span may be nil
+func (conv *converter) ensure(refid TraceSpanID, span *Span) (*trace.Span, error) {
+	id, err := convertTraceSpanID(refid)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := conv.timeline.SpanByID[id]
+	if !ok {
+		node = &trace.Span{}
+		conv.timeline.SpanByID[id] = node
+	}
+	updateSpanContent(node, id, span)
 
-			timeline.TimeRange = timeline.TimeRange.Expand(node.TimeRange)
-
-			for _, ref := range span.References {
-				switch ref.RefType {
-				case ChildOf:
-					parent, err := ensure(ref.TraceSpanID, nil, nil)
-					if err != nil {
-						return nil, err
-					}
-					parent.Children = append(parent.Children, node)
-					node.Parents = append(node.Parents, parent)
-				case FollowsFrom:
-					parent, err := ensure(ref.TraceSpanID, nil, nil)
-					if err != nil {
-						return nil, err
-					}
-					parent.FollowedBy = append(parent.FollowedBy, node)
-					node.FollowsFrom = append(node.FollowsFrom, parent)
-				}
+	if span != nil {
+		tr, ok := conv.traceByID[id.TraceID]
+		if !ok {
+			tr = &trace.Trace{
+				TraceID:   id.TraceID,
+				TimeRange: trace.InvalidRange,
 			}
+			conv.timeline.Traces = append(conv.timeline.Traces, tr)
+			conv.traceByID[id.TraceID] = tr
 		}
+		tr.Spans = append(tr.Spans, node)
+		tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
 	}
 
-	timeline.Sort()
-	return &timeline, nil
+	return node, nil
+}
+
+func (conv *converter) finish() *trace.Timeline {
+	conv.timeline.Processes = conv.processes.List
+	conv.timeline.Sort()
+	return &conv.timeline
 }
 
-func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span, processes map[ProcessID]Process) {
+func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span) {
 	if node.TraceSpanID.IsZero() {
 		node.TraceSpanID = id
 	}
@@ -125,33 +210,58 @@ func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span, proce
 
 	node.Tags = convertTags(span.Tags)
 	node.Logs = convertLogs(span.Logs)
+	updateSpanStatus(node)
 
-	if proc, ok := processes[span.ProcessID]; ok {
-		node.Tags = append([]trace.Tag{{Key: "service", Value: proc.ServiceName}}, node.Tags...)
-		node.Tags = append(node.Tags, convertTags(proc.Tags)...)
-	}
 	for _, w := range span.Warnings {
-		node.Tags = append(node.Tags, trace.Tag{Key: "warning", Value: w})
+		node.Tags = append(node.Tags, trace.Tag{Key: "warning", Value: trace.StringValue(w)})
 	}
 }
 
+// updateSpanProcess links the span to the shared process.
+func (conv *converter) updateSpanProcess(node *trace.Span, proc Process) {
+	node.Service = proc.ServiceName
+	node.Process = conv.processes.Add(proc.ServiceName, convertTags(proc.Tags))
+}
+
+// updateSpanStatus sets the kind and the status based on the
+// conventional tags.
+//
+// See https://opentracing.io/specification/conventions/
+func updateSpanStatus(node *trace.Span) {
+	for _, tag := range node.Tags {
+		switch tag.Key {
+		case "span.kind":
+			node.Kind = trace.ParseSpanKind(tag.Value.String())
+		case "error":
+			if tag.Value.Bool() || tag.Value.String() == "true" {
+				node.Status.Code = trace.StatusError
+			}
+		case "otel.status_code":
+			switch tag.Value.String() {
+			case "OK":
+				node.Status.Code = trace.StatusOK
+			case "ERROR":
+				node.Status.Code = trace.StatusError
+			}
+		case "otel.status_description":
+			node.Status.Message = tag.Value.String()
+		}
+	}
+}
+
+// See https://www.jaegertracing.io/docs/1.22/client-libraries/#value
 func convertTraceSpanID(id TraceSpanID) (trace.TraceSpanID, error) {
-	traceID, err := convertHexID(string(id.TraceID))
+	traceID, err := trace.ParseTraceID(string(id.TraceID))
 	if err != nil {
 		return trace.TraceSpanID{}, fmt.Errorf("invalid TraceID %q: %w", id.TraceID, err)
 	}
-	spanID, err := convertHexID(string(id.SpanID))
+	spanID, err := trace.ParseSpanID(string(id.SpanID))
 	if err != nil {
-		return trace.TraceSpanID{}, fmt.Errorf("invalid SpanID %q: %w", id.TraceID, err)
+		return trace.TraceSpanID{}, fmt.Errorf("invalid SpanID %q: %w", id.SpanID, err)
 	}
 
 	return trace.TraceSpanID{
-		TraceID: trace.TraceID(traceID),
-		SpanID:  trace.SpanID(spanID),
+		TraceID: traceID,
+		SpanID:  spanID,
 	}, nil
 }
-
-// See https://www.jaegertracing.io/docs/1.22/client-libraries/#value
-func convertHexID(v string) (int64, error) {
-	return strconv.ParseInt(v, 16, 64)
-}
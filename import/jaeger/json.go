@@ -81,5 +81,9 @@ type Tag struct {
 type TagType string
 
 const (
-	StringTag = TagType("string")
+	StringTag  = TagType("string")
+	BoolTag    = TagType("bool")
+	Int64Tag   = TagType("int64")
+	Float64Tag = TagType("float64")
+	BinaryTag  = TagType("binary")
 )
@@ -0,0 +1,87 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"loov.dev/traceview/import/internal/jsonstream"
+	"loov.dev/traceview/import/internal/sniff"
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of a jaeger file.
+func Detect(head []byte) bool {
+	obj, ok := sniff.FirstObject(head)
+	return ok && obj.Arrays == 0 && obj.Has("data")
+}
+
+// Read reads and converts a jaeger file.
+//
+// Traces are decoded one at a time and only the converted spans are
+// kept. Processes are usually listed after the spans of a trace, so
+// the spans decoded before the processes of their trace are remembered
+// until the end of the trace, when their process is set.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	conv := newConverter()
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	err := jsonstream.Object(dec, func(key string) error {
+		if key != "data" {
+			return jsonstream.Skip(dec)
+		}
+		return jsonstream.Array(dec, func() error {
+			return conv.decodeTrace(dec)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	return conv.finish(), nil
+}
+
+// decodeTrace decodes and converts a single trace.
+func (conv *converter) decodeTrace(dec *json.Decoder) error {
+	// Processes are usually listed after the spans,
+	// so they are added once the whole trace has been decoded.
+	type pending struct {
+		node      *trace.Span
+		processID ProcessID
+	}
+	var waiting []pending
+	var processes map[ProcessID]Process
+
+	err := jsonstream.Object(dec, func(key string) error {
+		switch key {
+		case "spans":
+			return jsonstream.Array(dec, func() error {
+				var span Span
+				if err := dec.Decode(&span); err != nil {
+					return err
+				}
+				node, err := conv.add(&span, processes)
+				if err != nil {
+					return err
+				}
+				if processes == nil {
+					waiting = append(waiting, pending{node: node, processID: span.ProcessID})
+				}
+				return nil
+			})
+		case "processes":
+			return dec.Decode(&processes)
+		default:
+			return jsonstream.Skip(dec)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range waiting {
+		if proc, ok := processes[p.processID]; ok {
+			conv.updateSpanProcess(p.node, proc)
+		}
+	}
+	return nil
+}
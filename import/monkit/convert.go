@@ -5,69 +5,94 @@ import (
 )
 
 func Convert(files ...File) (*trace.Timeline, error) {
-	var timeline trace.Timeline
+	conv := newConverter()
+	for i := range files {
+		file := files[i]
+		for k := range file {
+			if _, err := conv.add(&file[k]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return conv.finish(), nil
+}
 
-	traceByID := make(map[trace.TraceID]*trace.Trace)
-	timeline.SpanByID = make(map[trace.TraceSpanID]*trace.Span)
-	timeline.TimeRange = trace.InvalidRange
+// converter builds the timeline one span at a time.
+type converter struct {
+	timeline  trace.Timeline
+	traceByID map[trace.TraceID]*trace.Trace
+	processes trace.ProcessSet
+}
 
-	// span may be nil
-	ensure := func(spanID SpanID, traceID TraceID, span *Span) (*trace.Span, error) {
-		id := trace.TraceSpanID{
-			SpanID:  trace.SpanID(spanID),
-			TraceID: trace.TraceID(traceID),
-		}
+func newConverter() *converter {
+	return &converter{
+		timeline: trace.Timeline{
+			SpanByID:  make(map[trace.TraceSpanID]*trace.Span),
+			TimeRange: trace.InvalidRange,
+		},
+		traceByID: make(map[trace.TraceID]*trace.Trace),
+	}
+}
 
-		node, ok := timeline.SpanByID[id]
-		if !ok {
-			node = &trace.Span{}
-			timeline.SpanByID[id] = node
-		}
-		updateSpanContent(node, id, span)
-
-		if span != nil {
-			tr, ok := traceByID[id.TraceID]
-			if !ok {
-				tr = &trace.Trace{
-					TraceID:   id.TraceID,
-					TimeRange: trace.InvalidRange,
-				}
-				timeline.Traces = append(timeline.Traces, tr)
-				traceByID[id.TraceID] = tr
-			}
-			tr.Spans = append(tr.Spans, node)
-			tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
-		}
+// add converts span and links it to the parent.
+func (conv *converter) add(span *Span) (*trace.Span, error) {
+	node, err := conv.ensure(span.ID, span.Trace.ID, span)
+	if err != nil {
+		return nil, err
+	}
+	if span.Func.Package != "" {
+		node.Process = conv.processes.Add(span.Func.Package, nil)
+	}
+
+	conv.timeline.TimeRange = conv.timeline.TimeRange.Expand(node.TimeRange)
 
-		return node, nil
+	if span.ParentID != nil && TraceID(*span.ParentID) != span.Trace.ID {
+		parent, err := conv.ensure(*span.ParentID, span.Trace.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		parent.Children = append(parent.Children, node)
+		node.Parents = append(node.Parents, parent)
 	}
 
-	for i := range files {
-		file := files[i]
-		for k := range file {
-			span := &file[k]
+	return node, nil
+}
 
-			node, err := ensure(span.ID, span.Trace.ID, span)
-			if err != nil {
-				return nil, err
-			}
+// span may be nil
+func (conv *converter) ensure(spanID SpanID, traceID TraceID, span *Span) (*trace.Span, error) {
+	id := trace.TraceSpanID{
+		SpanID:  trace.SpanID(spanID),
+		TraceID: trace.TraceID{Low: uint64(traceID)},
+	}
 
-			timeline.TimeRange = timeline.TimeRange.Expand(node.TimeRange)
+	node, ok := conv.timeline.SpanByID[id]
+	if !ok {
+		node = &trace.Span{}
+		conv.timeline.SpanByID[id] = node
+	}
+	updateSpanContent(node, id, span)
 
-			if span.ParentID != nil && TraceID(*span.ParentID) != span.Trace.ID {
-				parent, err := ensure(*span.ParentID, span.Trace.ID, nil)
-				if err != nil {
-					return nil, err
-				}
-				parent.Children = append(parent.Children, node)
-				node.Parents = append(node.Parents, parent)
+	if span != nil {
+		tr, ok := conv.traceByID[id.TraceID]
+		if !ok {
+			tr = &trace.Trace{
+				TraceID:   id.TraceID,
+				TimeRange: trace.InvalidRange,
 			}
+			conv.timeline.Traces = append(conv.timeline.Traces, tr)
+			conv.traceByID[id.TraceID] = tr
 		}
+		tr.Spans = append(tr.Spans, node)
+		tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
 	}
 
-	timeline.Sort()
+	return node, nil
+}
 
-	return &timeline, nil
+func (conv *converter) finish() *trace.Timeline {
+	conv.timeline.Processes = conv.processes.List
+	conv.timeline.Sort()
+	return &conv.timeline
 }
 
 func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span) {
@@ -82,22 +107,28 @@ func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span) {
 	node.Start = span.Start.Time()
 	node.Finish = span.Finish.Time()
 
-	if span.Func.Package != "" {
-		node.Tags = append(node.Tags, trace.Tag{Key: "package", Value: span.Func.Package})
-	}
-	if span.Err != "" {
-		node.Tags = append(node.Tags, trace.Tag{Key: "error", Value: span.Err})
+	// Monkit spans are function calls within the same process,
+	// hence the package is the closest thing to a service.
+	node.Service = span.Func.Package
+	node.Kind = trace.SpanKindInternal
+	node.Status = trace.Status{Code: trace.StatusOK}
+	if span.Err != "" || span.Panicked {
+		node.Status = trace.Status{Code: trace.StatusError, Message: span.Err}
+		if node.Status.Message == "" {
+			node.Status.Message = "panicked"
+		}
 	}
+
 	if span.Panicked {
-		node.Tags = append(node.Tags, trace.Tag{Key: "panicked", Value: "true"})
+		node.Tags = append(node.Tags, trace.Tag{Key: "panicked", Value: trace.BoolValue(true)})
 	}
 	if span.Orphaned {
-		node.Tags = append(node.Tags, trace.Tag{Key: "orphaned", Value: "true"})
+		node.Tags = append(node.Tags, trace.Tag{Key: "orphaned", Value: trace.BoolValue(true)})
 	}
 	for _, arg := range span.Args {
-		node.Tags = append(node.Tags, trace.Tag{Key: "arg", Value: arg})
+		node.Tags = append(node.Tags, trace.Tag{Key: "arg", Value: trace.StringValue(arg)})
 	}
 	for _, ann := range span.Annotations {
-		node.Tags = append(node.Tags, trace.Tag{Key: ann[0], Value: ann[1]})
+		node.Tags = append(node.Tags, trace.Tag{Key: ann.Key, Value: trace.AnyValue(ann.Value)})
 	}
 }
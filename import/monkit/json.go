@@ -1,6 +1,8 @@
 package monkit
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"loov.dev/traceview/trace"
@@ -39,4 +41,26 @@ type Func struct {
 	Name    string `json:"name"`
 }
 
-type Annotation [2]string
+// Annotation is a key-value pair, the value is usually a string,
+// but may also be a number or a bool.
+type Annotation struct {
+	Key   string
+	Value any
+}
+
+func (ann *Annotation) UnmarshalJSON(data []byte) error {
+	var pair [2]any
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	key, ok := pair[0].(string)
+	if !ok {
+		return fmt.Errorf("invalid annotation key %v", pair[0])
+	}
+	ann.Key, ann.Value = key, pair[1]
+	return nil
+}
+
+func (ann Annotation) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]any{ann.Key, ann.Value})
+}
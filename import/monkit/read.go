@@ -0,0 +1,39 @@
+package monkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"loov.dev/traceview/import/internal/jsonstream"
+	"loov.dev/traceview/import/internal/sniff"
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of a monkit file.
+func Detect(head []byte) bool {
+	obj, ok := sniff.FirstObject(head)
+	return ok && obj.Arrays == 1 && obj.Has("func")
+}
+
+// Read reads and converts a monkit file.
+//
+// The file is a flat list of spans, which are decoded one at a time
+// and converted immediately, so only the converted spans are kept.
+// Parents that haven't been decoded yet are created as placeholders.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	conv := newConverter()
+	dec := json.NewDecoder(r)
+	err := jsonstream.Array(dec, func() error {
+		var span Span
+		if err := dec.Decode(&span); err != nil {
+			return err
+		}
+		_, err := conv.add(&span)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	return conv.finish(), nil
+}
@@ -0,0 +1,249 @@
+package otlp
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+
+	"loov.dev/traceview/trace"
+)
+
+func convertAttributes(attrs []KeyValue) []trace.Tag {
+	if len(attrs) == 0 {
+		return nil
+	}
+	out := make([]trace.Tag, len(attrs))
+	for i, kv := range attrs {
+		out[i] = trace.Tag{
+			Key:   kv.Key,
+			Value: convertValue(kv.Value),
+		}
+	}
+	return out
+}
+
+// convertValue keeps the type of the value, key-value lists
+// are formatted as strings.
+func convertValue(v AnyValue) trace.Value {
+	switch {
+	case v.StringValue != nil:
+		return trace.StringValue(*v.StringValue)
+	case v.BoolValue != nil:
+		return trace.BoolValue(*v.BoolValue)
+	case v.IntValue != nil:
+		return trace.IntValue(int64(*v.IntValue))
+	case v.DoubleValue != nil:
+		return trace.FloatValue(*v.DoubleValue)
+	case v.ArrayValue != nil:
+		values := make([]trace.Value, len(v.ArrayValue.Values))
+		for i, x := range v.ArrayValue.Values {
+			values[i] = convertValue(x)
+		}
+		return trace.ArrayValue(values...)
+	case v.BytesValue != nil:
+		return trace.BytesValue(v.BytesValue)
+	}
+	return trace.StringValue(v.String())
+}
+
+func convertEvents(events []Event) []trace.Log {
+	if len(events) == 0 {
+		return nil
+	}
+	out := make([]trace.Log, len(events))
+	for i, ev := range events {
+		fields := []trace.Tag{{Key: "event", Value: trace.StringValue(ev.Name)}}
+		fields = append(fields, convertAttributes(ev.Attributes)...)
+		out[i] = trace.Log{
+			Timestamp: ev.TimeUnixNano.Time(),
+			Fields:    fields,
+		}
+	}
+	return out
+}
+
+// Parse decodes OTLP/JSON or OTLP protobuf data.
+//
+// A binary stream may also start with '{', when the first message is
+// 123 bytes long, so protobuf is tried when JSON decoding fails.
+func Parse(data []byte) ([]File, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return ParseJSON(data)
+	}
+	if trimmed[0] != '{' {
+		return ParseProto(data)
+	}
+	files, err := ParseJSON(data)
+	if err != nil {
+		if files, protoErr := ParseProto(data); protoErr == nil {
+			return files, nil
+		}
+		return nil, err
+	}
+	return files, nil
+}
+
+func Convert(files ...File) (*trace.Timeline, error) {
+	var timeline trace.Timeline
+
+	traceByID := make(map[trace.TraceID]*trace.Trace)
+
+	timeline.SpanByID = make(map[trace.TraceSpanID]*trace.Span)
+	timeline.TimeRange = trace.InvalidRange
+
+	var processes trace.ProcessSet
+
+	// span may be nil
+	ensure := func(traceID TraceID, spanID SpanID, span *Span, process *trace.Process, scope []trace.Tag) (*trace.Span, error) {
+		id, err := convertTraceSpanID(traceID, spanID)
+		if err != nil {
+			return nil, err
+		}
+
+		node, ok := timeline.SpanByID[id]
+		if !ok {
+			node = &trace.Span{}
+			timeline.SpanByID[id] = node
+		}
+		updateSpanContent(node, id, span, process, scope)
+
+		if span != nil {
+			tr, ok := traceByID[id.TraceID]
+			if !ok {
+				tr = &trace.Trace{
+					TraceID:   id.TraceID,
+					TimeRange: trace.InvalidRange,
+				}
+				timeline.Traces = append(timeline.Traces, tr)
+				traceByID[id.TraceID] = tr
+			}
+			tr.Spans = append(tr.Spans, node)
+			tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
+		}
+
+		return node, nil
+	}
+
+	for i := range files {
+		for _, rs := range files[i].ResourceSpans {
+			process := convertResource(&processes, rs.Resource)
+
+			for _, ss := range slices.Concat(rs.ScopeSpans, rs.InstrumentationLibrarySpans) {
+				scope := ss.Scope
+				if ss.InstrumentationLibrary != nil {
+					scope = *ss.InstrumentationLibrary
+				}
+				scopeTags := convertScope(scope)
+
+				for k := range ss.Spans {
+					span := &ss.Spans[k]
+
+					node, err := ensure(span.TraceID, span.SpanID, span, process, scopeTags)
+					if err != nil {
+						return nil, err
+					}
+
+					timeline.TimeRange = timeline.TimeRange.Expand(node.TimeRange)
+
+					if span.ParentSpanID != "" {
+						parent, err := ensure(span.TraceID, span.ParentSpanID, nil, nil, nil)
+						if err != nil {
+							return nil, err
+						}
+						parent.Children = append(parent.Children, node)
+						node.Parents = append(node.Parents, parent)
+					}
+
+					for _, link := range span.Links {
+						traceID := link.TraceID
+						if traceID == "" {
+							traceID = span.TraceID
+						}
+						from, err := ensure(traceID, link.SpanID, nil, nil, nil)
+						if err != nil {
+							return nil, err
+						}
+						from.FollowedBy = append(from.FollowedBy, node)
+						node.FollowsFrom = append(node.FollowsFrom, from)
+					}
+				}
+			}
+		}
+	}
+
+	timeline.Processes = processes.List
+	timeline.Sort()
+	return &timeline, nil
+}
+
+// convertResource adds the resource to the processes,
+// using service.name as the service.
+func convertResource(processes *trace.ProcessSet, resource Resource) *trace.Process {
+	tags := convertAttributes(resource.Attributes)
+	service := ""
+	for _, tag := range tags {
+		if tag.Key == "service.name" {
+			service = tag.Value.String()
+		}
+	}
+	return processes.Add(service, tags)
+}
+
+func convertScope(scope Scope) []trace.Tag {
+	var tags []trace.Tag
+	if scope.Name != "" {
+		tags = append(tags, trace.Tag{Key: "otel.scope.name", Value: trace.StringValue(scope.Name)})
+	}
+	if scope.Version != "" {
+		tags = append(tags, trace.Tag{Key: "otel.scope.version", Value: trace.StringValue(scope.Version)})
+	}
+	return tags
+}
+
+func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span, process *trace.Process, scope []trace.Tag) {
+	if node.TraceSpanID.IsZero() {
+		node.TraceSpanID = id
+	}
+	if span == nil {
+		return
+	}
+
+	node.Caption = span.Name
+	node.Start = span.StartTimeUnixNano.Time()
+	node.Finish = span.EndTimeUnixNano.Time()
+
+	node.Service = process.Service
+	node.Process = process
+	node.Kind = trace.ParseSpanKind(span.Kind.String())
+	node.Status.Message = span.Status.Message
+	switch span.Status.Code {
+	case StatusCodeOK:
+		node.Status.Code = trace.StatusOK
+	case StatusCodeError:
+		node.Status.Code = trace.StatusError
+	}
+
+	var tags []trace.Tag
+	tags = append(tags, convertAttributes(span.Attributes)...)
+	tags = append(tags, scope...)
+
+	node.Tags = tags
+	node.Logs = convertEvents(span.Events)
+}
+
+func convertTraceSpanID(traceID TraceID, spanID SpanID) (trace.TraceSpanID, error) {
+	tid, err := trace.ParseTraceID(string(traceID))
+	if err != nil {
+		return trace.TraceSpanID{}, fmt.Errorf("invalid TraceID %q: %w", traceID, err)
+	}
+	sid, err := trace.ParseSpanID(string(spanID))
+	if err != nil {
+		return trace.TraceSpanID{}, fmt.Errorf("invalid SpanID %q: %w", spanID, err)
+	}
+
+	return trace.TraceSpanID{
+		TraceID: tid,
+		SpanID:  sid,
+	}, nil
+}
@@ -0,0 +1,277 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"loov.dev/traceview/trace"
+)
+
+// File corresponds to ExportTraceServiceRequest as written by
+// the OpenTelemetry collector file exporter.
+type File struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+// ParseJSON decodes one or more concatenated JSON messages, since
+// the collector file exporter writes one request per line.
+func ParseJSON(data []byte) ([]File, error) {
+	var files []File
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var file File
+		err := dec.Decode(&file)
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+	SchemaURL  string       `json:"schemaUrl,omitempty"`
+
+	// InstrumentationLibrarySpans is the name used by older exporters.
+	InstrumentationLibrarySpans []ScopeSpans `json:"instrumentationLibrarySpans,omitempty"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type ScopeSpans struct {
+	Scope     Scope  `json:"scope"`
+	Spans     []Span `json:"spans"`
+	SchemaURL string `json:"schemaUrl,omitempty"`
+
+	// InstrumentationLibrary is the name used by older exporters.
+	InstrumentationLibrary *Scope `json:"instrumentationLibrary,omitempty"`
+}
+
+type Scope struct {
+	Name       string     `json:"name"`
+	Version    string     `json:"version"`
+	Attributes []KeyValue `json:"attributes,omitempty"`
+}
+
+type TraceID string
+type SpanID string
+
+type Span struct {
+	TraceID           TraceID    `json:"traceId"`
+	SpanID            SpanID     `json:"spanId"`
+	TraceState        string     `json:"traceState,omitempty"`
+	ParentSpanID      SpanID     `json:"parentSpanId,omitempty"`
+	Flags             uint32     `json:"flags,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano UnixNano   `json:"startTimeUnixNano"`
+	EndTimeUnixNano   UnixNano   `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes"`
+	Events            []Event    `json:"events"`
+	Links             []Link     `json:"links"`
+	Status            Status     `json:"status"`
+}
+
+type Event struct {
+	TimeUnixNano UnixNano   `json:"timeUnixNano"`
+	Name         string     `json:"name"`
+	Attributes   []KeyValue `json:"attributes"`
+}
+
+type Link struct {
+	TraceID    TraceID    `json:"traceId"`
+	SpanID     SpanID     `json:"spanId"`
+	TraceState string     `json:"traceState,omitempty"`
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type Status struct {
+	Code    StatusCode `json:"code"`
+	Message string     `json:"message,omitempty"`
+}
+
+// UnixNano is a timestamp in nanoseconds since the epoch.
+//
+// The protobuf JSON mapping encodes 64-bit integers as strings,
+// however some exporters write them as plain numbers.
+type UnixNano uint64
+
+func (n UnixNano) Std() time.Duration { return time.Duration(n) }
+func (n UnixNano) Time() trace.Time   { return trace.NewTime(n.Std()) }
+
+func (n *UnixNano) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalUint64(data)
+	*n = UnixNano(v)
+	return err
+}
+
+type SpanKind int32
+
+const (
+	SpanKindUnspecified = SpanKind(0)
+	SpanKindInternal    = SpanKind(1)
+	SpanKindServer      = SpanKind(2)
+	SpanKindClient      = SpanKind(3)
+	SpanKindProducer    = SpanKind(4)
+	SpanKindConsumer    = SpanKind(5)
+)
+
+var spanKindNames = []string{
+	SpanKindUnspecified: "SPAN_KIND_UNSPECIFIED",
+	SpanKindInternal:    "SPAN_KIND_INTERNAL",
+	SpanKindServer:      "SPAN_KIND_SERVER",
+	SpanKindClient:      "SPAN_KIND_CLIENT",
+	SpanKindProducer:    "SPAN_KIND_PRODUCER",
+	SpanKindConsumer:    "SPAN_KIND_CONSUMER",
+}
+
+// String returns the lowercase kind name as used by the span.kind tag.
+func (kind SpanKind) String() string {
+	if kind < 0 || int(kind) >= len(spanKindNames) {
+		return strconv.Itoa(int(kind))
+	}
+	return strings.ToLower(strings.TrimPrefix(spanKindNames[kind], "SPAN_KIND_"))
+}
+
+func (kind *SpanKind) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum(data, spanKindNames)
+	*kind = SpanKind(v)
+	return err
+}
+
+type StatusCode int32
+
+const (
+	StatusCodeUnset = StatusCode(0)
+	StatusCodeOK    = StatusCode(1)
+	StatusCodeError = StatusCode(2)
+)
+
+var statusCodeNames = []string{
+	StatusCodeUnset: "STATUS_CODE_UNSET",
+	StatusCodeOK:    "STATUS_CODE_OK",
+	StatusCodeError: "STATUS_CODE_ERROR",
+}
+
+// String returns the uppercase status name as used by the otel.status_code tag.
+func (code StatusCode) String() string {
+	if code < 0 || int(code) >= len(statusCodeNames) {
+		return strconv.Itoa(int(code))
+	}
+	return strings.TrimPrefix(statusCodeNames[code], "STATUS_CODE_")
+}
+
+func (code *StatusCode) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalEnum(data, statusCodeNames)
+	*code = StatusCode(v)
+	return err
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds exactly one of the values.
+type AnyValue struct {
+	StringValue *string       `json:"stringValue,omitempty"`
+	BoolValue   *bool         `json:"boolValue,omitempty"`
+	IntValue    *Int64        `json:"intValue,omitempty"`
+	DoubleValue *float64      `json:"doubleValue,omitempty"`
+	ArrayValue  *ArrayValue   `json:"arrayValue,omitempty"`
+	KvlistValue *KeyValueList `json:"kvlistValue,omitempty"`
+	BytesValue  []byte        `json:"bytesValue,omitempty"`
+}
+
+type ArrayValue struct {
+	Values []AnyValue `json:"values"`
+}
+
+type KeyValueList struct {
+	Values []KeyValue `json:"values"`
+}
+
+// Int64 is an integer that may be encoded as a JSON string or number.
+type Int64 int64
+
+func (v *Int64) UnmarshalJSON(data []byte) error {
+	var s json.Number
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	x, err := strconv.ParseInt(string(s), 10, 64)
+	*v = Int64(x)
+	return err
+}
+
+// String formats the value similarly to how the OpenTelemetry SDK does.
+func (v AnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(*v.DoubleValue, 'g', -1, 64)
+	case v.ArrayValue != nil:
+		values := make([]string, len(v.ArrayValue.Values))
+		for i, x := range v.ArrayValue.Values {
+			values[i] = x.String()
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case v.KvlistValue != nil:
+		values := make([]string, len(v.KvlistValue.Values))
+		for i, kv := range v.KvlistValue.Values {
+			values[i] = kv.Key + "=" + kv.Value.String()
+		}
+		return "{" + strings.Join(values, ", ") + "}"
+	case v.BytesValue != nil:
+		return fmt.Sprintf("%x", v.BytesValue)
+	}
+	return ""
+}
+
+func unmarshalUint64(data []byte) (uint64, error) {
+	var s json.Number
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, err
+	}
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(string(s), 10, 64)
+}
+
+// unmarshalEnum accepts either the numeric value or the enum name.
+func unmarshalEnum(data []byte, names []string) (int32, error) {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var v int32
+		err := json.Unmarshal(data, &v)
+		return v, err
+	}
+	for i, n := range names {
+		if n == name {
+			return int32(i), nil
+		}
+	}
+	v, err := strconv.ParseInt(name, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown enum value %q", name)
+	}
+	return int32(v), nil
+}
@@ -0,0 +1,462 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// UnmarshalProto decodes a binary ExportTraceServiceRequest (or TracesData)
+// protobuf message.
+func UnmarshalProto(data []byte, file *File) error {
+	return decodeMessage(data, func(r *protoReader, num uint64, typ wireType) error {
+		switch num {
+		case 1:
+			var rs ResourceSpans
+			if err := r.message(typ, rs.decode); err != nil {
+				return err
+			}
+			file.ResourceSpans = append(file.ResourceSpans, rs)
+			return nil
+		}
+		return r.skip(typ)
+	})
+}
+
+// UnmarshalProtoStream decodes a stream of varint length-delimited
+// ExportTraceServiceRequest messages.
+func UnmarshalProtoStream(data []byte) ([]File, error) {
+	var files []File
+	r := protoReader{data: data}
+	for !r.done() {
+		size, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		msg, err := r.take(size)
+		if err != nil {
+			return nil, err
+		}
+		var file File
+		if err := UnmarshalProto(msg, &file); err != nil {
+			return nil, fmt.Errorf("message %d: %w", len(files), err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// resourceSpansTag is the tag of the resource_spans field,
+// which starts every non-empty message.
+const resourceSpansTag = 0x0a
+
+// ParseProto decodes either a length-delimited stream or a single message.
+//
+// A stream is tried first, because a stream may also decode as a single
+// message with unknown fields. The data is treated as a stream only when
+// the messages cover the whole input and each starts with resource_spans.
+func ParseProto(data []byte) ([]File, error) {
+	messages, complete := protoStreamPrefix(data)
+	var streamErr error
+	if messages > 0 {
+		files, err := UnmarshalProtoStream(data)
+		if err == nil && complete {
+			return files, nil
+		}
+		streamErr = err
+	}
+
+	var file File
+	if err := UnmarshalProto(data, &file); err != nil {
+		if streamErr != nil {
+			// Report the stream error, e.g. for a truncated stream.
+			return nil, streamErr
+		}
+		return nil, err
+	}
+	if len(data) > 0 && len(file.ResourceSpans) == 0 {
+		return nil, errors.New("no resource spans")
+	}
+	return []File{file}, nil
+}
+
+// protoStreamPrefix returns the number of leading length-delimited
+// messages that start with resource_spans and whether they cover
+// the whole data.
+func protoStreamPrefix(data []byte) (messages int, complete bool) {
+	r := protoReader{data: data}
+	for !r.done() {
+		size, err := r.varint()
+		if err != nil {
+			return messages, false
+		}
+		msg, err := r.take(size)
+		if err != nil || len(msg) == 0 || msg[0] != resourceSpansTag {
+			return messages, false
+		}
+		messages++
+	}
+	return messages, messages > 0
+}
+
+func (rs *ResourceSpans) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.message(typ, rs.Resource.decode)
+	case 2, 1000:
+		var ss ScopeSpans
+		if err := r.message(typ, ss.decode); err != nil {
+			return err
+		}
+		rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		return nil
+	case 3:
+		return r.string(typ, &rs.SchemaURL)
+	}
+	return r.skip(typ)
+}
+
+func (res *Resource) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.keyValue(typ, &res.Attributes)
+	}
+	return r.skip(typ)
+}
+
+func (ss *ScopeSpans) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.message(typ, ss.Scope.decode)
+	case 2:
+		var span Span
+		if err := r.message(typ, span.decode); err != nil {
+			return err
+		}
+		ss.Spans = append(ss.Spans, span)
+		return nil
+	case 3:
+		return r.string(typ, &ss.SchemaURL)
+	}
+	return r.skip(typ)
+}
+
+func (scope *Scope) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.string(typ, &scope.Name)
+	case 2:
+		return r.string(typ, &scope.Version)
+	case 3:
+		return r.keyValue(typ, &scope.Attributes)
+	}
+	return r.skip(typ)
+}
+
+func (span *Span) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.hexID(typ, (*string)(&span.TraceID))
+	case 2:
+		return r.hexID(typ, (*string)(&span.SpanID))
+	case 3:
+		return r.string(typ, &span.TraceState)
+	case 4:
+		return r.hexID(typ, (*string)(&span.ParentSpanID))
+	case 5:
+		return r.string(typ, &span.Name)
+	case 6:
+		v, err := r.varintField(typ)
+		span.Kind = SpanKind(v)
+		return err
+	case 7:
+		v, err := r.fixed64Field(typ)
+		span.StartTimeUnixNano = UnixNano(v)
+		return err
+	case 8:
+		v, err := r.fixed64Field(typ)
+		span.EndTimeUnixNano = UnixNano(v)
+		return err
+	case 9:
+		return r.keyValue(typ, &span.Attributes)
+	case 11:
+		var ev Event
+		if err := r.message(typ, ev.decode); err != nil {
+			return err
+		}
+		span.Events = append(span.Events, ev)
+		return nil
+	case 13:
+		var link Link
+		if err := r.message(typ, link.decode); err != nil {
+			return err
+		}
+		span.Links = append(span.Links, link)
+		return nil
+	case 15:
+		return r.message(typ, span.Status.decode)
+	case 16:
+		v, err := r.fixed32Field(typ)
+		span.Flags = v
+		return err
+	}
+	return r.skip(typ)
+}
+
+func (ev *Event) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		v, err := r.fixed64Field(typ)
+		ev.TimeUnixNano = UnixNano(v)
+		return err
+	case 2:
+		return r.string(typ, &ev.Name)
+	case 3:
+		return r.keyValue(typ, &ev.Attributes)
+	}
+	return r.skip(typ)
+}
+
+func (link *Link) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.hexID(typ, (*string)(&link.TraceID))
+	case 2:
+		return r.hexID(typ, (*string)(&link.SpanID))
+	case 3:
+		return r.string(typ, &link.TraceState)
+	case 4:
+		return r.keyValue(typ, &link.Attributes)
+	}
+	return r.skip(typ)
+}
+
+func (status *Status) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 2:
+		return r.string(typ, &status.Message)
+	case 3:
+		v, err := r.varintField(typ)
+		status.Code = StatusCode(v)
+		return err
+	}
+	return r.skip(typ)
+}
+
+func (kv *KeyValue) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		return r.string(typ, &kv.Key)
+	case 2:
+		return r.message(typ, kv.Value.decode)
+	}
+	return r.skip(typ)
+}
+
+func (v *AnyValue) decode(r *protoReader, num uint64, typ wireType) error {
+	switch num {
+	case 1:
+		var s string
+		err := r.string(typ, &s)
+		v.StringValue = &s
+		return err
+	case 2:
+		x, err := r.varintField(typ)
+		b := x != 0
+		v.BoolValue = &b
+		return err
+	case 3:
+		x, err := r.varintField(typ)
+		i := Int64(x)
+		v.IntValue = &i
+		return err
+	case 4:
+		x, err := r.fixed64Field(typ)
+		f := math.Float64frombits(x)
+		v.DoubleValue = &f
+		return err
+	case 5:
+		v.ArrayValue = &ArrayValue{}
+		return r.message(typ, func(r *protoReader, num uint64, typ wireType) error {
+			if num != 1 {
+				return r.skip(typ)
+			}
+			var value AnyValue
+			if err := r.message(typ, value.decode); err != nil {
+				return err
+			}
+			v.ArrayValue.Values = append(v.ArrayValue.Values, value)
+			return nil
+		})
+	case 6:
+		v.KvlistValue = &KeyValueList{}
+		return r.message(typ, func(r *protoReader, num uint64, typ wireType) error {
+			if num != 1 {
+				return r.skip(typ)
+			}
+			return r.keyValue(typ, &v.KvlistValue.Values)
+		})
+	case 7:
+		data, err := r.bytesField(typ)
+		v.BytesValue = append([]byte{}, data...)
+		return err
+	}
+	return r.skip(typ)
+}
+
+type wireType uint8
+
+const (
+	wireVarint  = wireType(0)
+	wireFixed64 = wireType(1)
+	wireBytes   = wireType(2)
+	wireFixed32 = wireType(5)
+)
+
+var errTruncated = errors.New("truncated protobuf message")
+
+// protoReader implements the subset of protobuf wire format needed for OTLP.
+type protoReader struct {
+	data []byte
+}
+
+func decodeMessage(data []byte, field func(r *protoReader, num uint64, typ wireType) error) error {
+	r := protoReader{data: data}
+	for !r.done() {
+		tag, err := r.varint()
+		if err != nil {
+			return err
+		}
+		num, typ := tag>>3, wireType(tag&7)
+		if num == 0 {
+			return errors.New("invalid protobuf field number 0")
+		}
+		if err := field(&r, num, typ); err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+	}
+	return nil
+}
+
+func (r *protoReader) done() bool { return len(r.data) == 0 }
+
+func (r *protoReader) varint() (uint64, error) {
+	var v uint64
+	for i := 0; i < 10; i++ {
+		if i >= len(r.data) {
+			return 0, errTruncated
+		}
+		b := r.data[i]
+		v |= uint64(b&0x7F) << (7 * i)
+		if b < 0x80 {
+			r.data = r.data[i+1:]
+			return v, nil
+		}
+	}
+	return 0, errors.New("varint overflow")
+}
+
+func (r *protoReader) take(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)) {
+		return nil, errTruncated
+	}
+	data := r.data[:n]
+	r.data = r.data[n:]
+	return data, nil
+}
+
+func (r *protoReader) skip(typ wireType) error {
+	switch typ {
+	case wireVarint:
+		_, err := r.varint()
+		return err
+	case wireFixed64:
+		_, err := r.take(8)
+		return err
+	case wireBytes:
+		_, err := r.bytesField(typ)
+		return err
+	case wireFixed32:
+		_, err := r.take(4)
+		return err
+	}
+	return fmt.Errorf("unsupported wire type %d", typ)
+}
+
+func expect(typ, want wireType) error {
+	if typ != want {
+		return fmt.Errorf("wire type %d, expected %d", typ, want)
+	}
+	return nil
+}
+
+func (r *protoReader) varintField(typ wireType) (uint64, error) {
+	if err := expect(typ, wireVarint); err != nil {
+		return 0, err
+	}
+	return r.varint()
+}
+
+func (r *protoReader) fixed64Field(typ wireType) (uint64, error) {
+	if err := expect(typ, wireFixed64); err != nil {
+		return 0, err
+	}
+	b, err := r.take(8)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(b[0]) | uint64(b[1])<<8 | uint64(b[2])<<16 | uint64(b[3])<<24 |
+		uint64(b[4])<<32 | uint64(b[5])<<40 | uint64(b[6])<<48 | uint64(b[7])<<56, nil
+}
+
+func (r *protoReader) fixed32Field(typ wireType) (uint32, error) {
+	if err := expect(typ, wireFixed32); err != nil {
+		return 0, err
+	}
+	b, err := r.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[0]) | uint32(b[1])<<8 | uint32(b[2])<<16 | uint32(b[3])<<24, nil
+}
+
+func (r *protoReader) bytesField(typ wireType) ([]byte, error) {
+	if err := expect(typ, wireBytes); err != nil {
+		return nil, err
+	}
+	size, err := r.varint()
+	if err != nil {
+		return nil, err
+	}
+	return r.take(size)
+}
+
+func (r *protoReader) string(typ wireType, s *string) error {
+	data, err := r.bytesField(typ)
+	*s = string(data)
+	return err
+}
+
+func (r *protoReader) hexID(typ wireType, s *string) error {
+	data, err := r.bytesField(typ)
+	*s = hex.EncodeToString(data)
+	return err
+}
+
+func (r *protoReader) message(typ wireType, field func(r *protoReader, num uint64, typ wireType) error) error {
+	data, err := r.bytesField(typ)
+	if err != nil {
+		return err
+	}
+	return decodeMessage(data, field)
+}
+
+func (r *protoReader) keyValue(typ wireType, list *[]KeyValue) error {
+	var kv KeyValue
+	if err := r.message(typ, kv.decode); err != nil {
+		return err
+	}
+	*list = append(*list, kv)
+	return nil
+}
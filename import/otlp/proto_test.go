@@ -0,0 +1,194 @@
+package otlp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// field encodes a length-delimited protobuf field.
+func field(num byte, data []byte) []byte {
+	out := []byte{num<<3 | byte(wireBytes)}
+	for n := len(data); ; n >>= 7 {
+		if n < 0x80 {
+			out = append(out, byte(n))
+			break
+		}
+		out = append(out, byte(n)|0x80)
+	}
+	return append(out, data...)
+}
+
+// delimited prefixes each message with its varint length.
+func delimited(messages ...[]byte) []byte {
+	var out []byte
+	for _, msg := range messages {
+		out = append(out, field(0, msg)[1:]...)
+	}
+	return out
+}
+
+// request encodes a message with a single span named name.
+func request(name string) []byte {
+	span := field(5, []byte(name))
+	return field(1, field(2, field(2, span)))
+}
+
+// requestSize encodes a message with a single span of the given total size.
+func requestSize(size int) (string, []byte) {
+	name := strings.Repeat("x", size-8)
+	return name, request(name)
+}
+
+func TestParseProto(t *testing.T) {
+	name29, msg29 := requestSize(29)
+	name123, msg123 := requestSize(123)
+	if len(msg29) != 29 || len(msg123) != 123 {
+		t.Fatalf("invalid test messages: %d, %d", len(msg29), len(msg123))
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		spans [][]string
+		err   string
+	}{
+		{name: "empty", data: nil, spans: [][]string{nil}},
+		{name: "single", data: request("a"), spans: [][]string{{"a"}}},
+		{
+			name:  "single multiple resources",
+			data:  append(request("a"), request("b")...),
+			spans: [][]string{{"a", "b"}},
+		},
+		{
+			name:  "stream",
+			data:  delimited(request("a"), request("b")),
+			spans: [][]string{{"a"}, {"b"}},
+		},
+		{
+			name:  "stream 29 byte message",
+			data:  delimited(msg29, request("b")),
+			spans: [][]string{{name29}, {"b"}},
+		},
+		{
+			name:  "stream 123 byte message",
+			data:  delimited(msg123, request("b")),
+			spans: [][]string{{name123}, {"b"}},
+		},
+		{
+			name: "truncated single",
+			data: request("abc")[:7],
+			err:  "truncated protobuf message",
+		},
+		{
+			name: "truncated stream",
+			data: delimited(request("a"), request("b"))[:12],
+			err:  "truncated protobuf message",
+		},
+		{
+			name: "unknown wire type",
+			data: append(request("a"), 2<<3|6, 0),
+			err:  "unsupported wire type 6",
+		},
+		{
+			name: "unknown nested wire type",
+			data: field(1, []byte{5<<3 | 7, 0}),
+			err:  "unsupported wire type 7",
+		},
+		{
+			name: "no resource spans",
+			data: []byte{2<<3 | byte(wireVarint), 1},
+			err:  "no resource spans",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			files, err := ParseProto(test.data)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, expected %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := spanNames(files); !reflect.DeepEqual(got, test.spans) {
+				t.Fatalf("got %q, expected %q", got, test.spans)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	name123, msg123 := requestSize(123)
+	stream := delimited(msg123, request("b"))
+	if stream[0] != '{' {
+		t.Fatalf("stream starts with %q", stream[0])
+	}
+
+	files, err := Parse(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := spanNames(files), [][]string{{name123}, {"b"}}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %q, expected %q", got, exp)
+	}
+
+	files, err = Parse([]byte(`{"resourceSpans":[{"scopeSpans":[{"spans":[{"name":"a"}]}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := spanNames(files), [][]string{{"a"}}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %q, expected %q", got, exp)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	_, msg29 := requestSize(29)
+	_, msg123 := requestSize(123)
+
+	tests := []struct {
+		name string
+		head []byte
+		exp  bool
+	}{
+		{name: "empty", head: nil, exp: false},
+		{name: "json", head: []byte(`{"resourceSpans":[]}`), exp: true},
+		{name: "single", head: request("a"), exp: true},
+		{name: "stream", head: delimited(request("a")), exp: true},
+		{name: "stream 29 byte message", head: delimited(msg29), exp: true},
+		{name: "stream 123 byte message", head: delimited(msg123), exp: true},
+		{name: "single partial head", head: request(strings.Repeat("x", 200))[:40], exp: true},
+		{name: "text", head: []byte("\nhello world"), exp: false},
+		{name: "resource spans too long", head: []byte{3, 0x0a, 5, 0x0a, 0}, exp: false},
+		{name: "unknown resource field", head: field(1, field(7, []byte("x"))), exp: false},
+		{name: "first field too long", head: []byte{0x0a, 5, 2<<3 | 2, 9, 'a', 'b', 'c'}, exp: false},
+		{name: "partial first field", head: field(1, field(2, []byte("abc")))[:4], exp: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Detect(test.head); got != test.exp {
+				t.Fatalf("got %v, expected %v", got, test.exp)
+			}
+		})
+	}
+}
+
+func spanNames(files []File) [][]string {
+	var names [][]string
+	for _, file := range files {
+		var list []string
+		for _, rs := range file.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					list = append(list, span.Name)
+				}
+			}
+		}
+		names = append(names, list)
+	}
+	return names
+}
@@ -0,0 +1,101 @@
+package otlp
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"loov.dev/traceview/import/internal/sniff"
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of an OTLP/JSON
+// or an OTLP protobuf file.
+func Detect(head []byte) bool {
+	if sniff.IsJSON(head) {
+		obj, ok := sniff.FirstObject(head)
+		if ok && obj.Arrays == 0 && obj.Has("resourceSpans") {
+			return true
+		}
+		// A length-delimited stream may also start with '{'.
+	}
+
+	return detectProto(head, false) || detectProto(head, true)
+}
+
+// detectProto reports whether head starts with a resource_spans field,
+// optionally prefixed by the message length.
+//
+// The resource_spans length must fit into the length-delimited message.
+// The fields of resource_spans are walked until the end of head and
+// their lengths must fit into resource_spans. A field cut by the end
+// of head is accepted, since head is only a prefix of the file.
+func detectProto(head []byte, delimited bool) bool {
+	r := protoReader{data: head}
+
+	var limit uint64 = math.MaxUint64
+	if delimited {
+		size, err := r.varint()
+		if err != nil || size == 0 {
+			return false
+		}
+		limit = size
+	}
+
+	before := len(r.data)
+	if tag, err := r.varint(); err != nil || tag != resourceSpansTag {
+		return false
+	}
+	size, err := r.varint()
+	if err != nil || size == 0 {
+		return false
+	}
+	if header := uint64(before - len(r.data)); size > limit-header {
+		return false
+	}
+
+	rs := protoReader{data: r.data[:min(size, uint64(len(r.data)))]}
+	for remaining, walked := size, false; !rs.done(); walked = true {
+		start := len(rs.data)
+		tag, err := rs.varint()
+		if err != nil {
+			// The tag may be cut by the end of head.
+			return walked && err == errTruncated
+		}
+		num, typ := tag>>3, wireType(tag&7)
+		if typ != wireBytes || (num != 1 && num != 2 && num != 3 && num != 1000) {
+			return false
+		}
+		fieldSize, err := rs.varint()
+		if err != nil {
+			return walked && err == errTruncated
+		}
+		header := uint64(start - len(rs.data))
+		if fieldSize > remaining-header {
+			return false
+		}
+		remaining -= header + fieldSize
+		if _, err := rs.take(fieldSize); err != nil {
+			// The field continues past the end of head.
+			return true
+		}
+	}
+	return true
+}
+
+// Read reads and converts an OTLP/JSON or an OTLP protobuf file.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	files, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	timeline, err := Convert(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+	return timeline, nil
+}
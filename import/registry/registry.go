@@ -0,0 +1,124 @@
+// Package registry lists the supported trace formats.
+package registry
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/zeebo/clingy"
+
+	"loov.dev/traceview/import/chrome"
+	"loov.dev/traceview/import/gotrace"
+	"loov.dev/traceview/import/jaeger"
+	"loov.dev/traceview/import/monkit"
+	"loov.dev/traceview/import/otlp"
+	"loov.dev/traceview/import/zipkin"
+	"loov.dev/traceview/trace"
+)
+
+// Format describes how to detect and load a trace format.
+type Format struct {
+	Name        string
+	Description string
+
+	// Detect reports whether head, the beginning of the file,
+	// looks like this format.
+	Detect func(head []byte) bool
+	// Read reads and converts the whole file.
+	Read func(r io.Reader) (*trace.Timeline, error)
+	// Flags registers the format specific flags and returns Read
+	// configured by them. It's nil when the format doesn't have flags.
+	Flags func(params clingy.Parameters) func(r io.Reader) (*trace.Timeline, error)
+}
+
+// Formats lists all the supported formats in the order of detection.
+var Formats = []Format{
+	{Name: "jaeger", Description: "load jaeger .json trace", Detect: jaeger.Detect, Read: jaeger.Read},
+	{Name: "monkit", Description: "load monkit .json trace", Detect: monkit.Detect, Read: monkit.Read},
+	{Name: "otlp", Description: "load OpenTelemetry OTLP .json or .pb trace", Detect: otlp.Detect, Read: otlp.Read},
+	{Name: "zipkin", Description: "load zipkin v2 .json trace", Detect: zipkin.Detect, Read: zipkin.Read},
+	{Name: "chrome", Description: "load chrome trace event .json trace", Detect: chrome.Detect, Read: chrome.Read},
+	{Name: "gotrace", Description: "load go runtime/trace execution trace", Detect: gotrace.Detect, Read: gotrace.Read, Flags: gotraceFlags},
+}
+
+func gotraceFlags(params clingy.Parameters) func(r io.Reader) (*trace.Timeline, error) {
+	goroutines := params.Flag("goroutines", "include goroutine execution", true,
+		clingy.Transform(strconv.ParseBool), clingy.Boolean).(bool)
+	return func(r io.Reader) (*trace.Timeline, error) {
+		return gotrace.Convert(r, gotrace.Options{Goroutines: goroutines})
+	}
+}
+
+// Setup registers the format specific flags and returns
+// the format configured by them.
+func (format Format) Setup(params clingy.Parameters) Format {
+	if format.Flags != nil {
+		format.Read = format.Flags(params)
+	}
+	return format
+}
+
+// Setup registers the flags of all formats and returns
+// the formats configured by them.
+func Setup(params clingy.Parameters) []Format {
+	formats := make([]Format, 0, len(Formats))
+	for _, format := range Formats {
+		formats = append(formats, format.Setup(params))
+	}
+	return formats
+}
+
+// HeadSize is the number of bytes used for detecting the format.
+const HeadSize = 4 << 10
+
+// ErrUnknownFormat is returned when none of the formats match.
+var ErrUnknownFormat = errors.New("unknown trace format")
+
+// Lookup finds a format by name.
+func Lookup(name string) (Format, bool) {
+	for _, format := range Formats {
+		if format.Name == name {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// Detect finds the format that matches head.
+func Detect(head []byte) (Format, bool) {
+	return DetectIn(Formats, head)
+}
+
+// DetectIn finds the format in formats that matches head.
+func DetectIn(formats []Format, head []byte) (Format, bool) {
+	for _, format := range formats {
+		if format.Detect(head) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// Read detects the format of r and converts it.
+func Read(r io.Reader) (*trace.Timeline, Format, error) {
+	return ReadIn(Formats, r)
+}
+
+// ReadIn detects the format of r from formats and converts it.
+func ReadIn(formats []Format, r io.Reader) (*trace.Timeline, Format, error) {
+	br := bufio.NewReaderSize(r, HeadSize)
+	head, err := br.Peek(HeadSize)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, Format{}, err
+	}
+
+	format, ok := DetectIn(formats, head)
+	if !ok {
+		return nil, Format{}, ErrUnknownFormat
+	}
+
+	timeline, err := format.Read(br)
+	return timeline, format, err
+}
@@ -0,0 +1,277 @@
+package zipkin
+
+import (
+	"fmt"
+	"sort"
+
+	"loov.dev/traceview/trace"
+)
+
+func convertTags(tags map[string]string) []trace.Tag {
+	if len(tags) == 0 {
+		return nil
+	}
+	out := make([]trace.Tag, 0, len(tags))
+	for key, value := range tags {
+		out = append(out, trace.Tag{Key: key, Value: trace.StringValue(value)})
+	}
+	sort.Slice(out, func(i, k int) bool {
+		return out[i].Key < out[k].Key
+	})
+	return out
+}
+
+func convertAnnotations(annotations []Annotation) []trace.Log {
+	if len(annotations) == 0 {
+		return nil
+	}
+	out := make([]trace.Log, len(annotations))
+	for i, ann := range annotations {
+		out[i] = trace.Log{
+			Timestamp: ann.Timestamp.Time(),
+			Fields:    []trace.Tag{{Key: "event", Value: trace.StringValue(ann.Value)}},
+		}
+	}
+	return out
+}
+
+func convertEndpoint(prefix string, endpoint *Endpoint) []trace.Tag {
+	if endpoint == nil {
+		return nil
+	}
+	var tags []trace.Tag
+	if endpoint.IPv4 != "" {
+		tags = append(tags, trace.Tag{Key: prefix + "ipv4", Value: trace.StringValue(endpoint.IPv4)})
+	}
+	if endpoint.IPv6 != "" {
+		tags = append(tags, trace.Tag{Key: prefix + "ipv6", Value: trace.StringValue(endpoint.IPv6)})
+	}
+	if endpoint.Port != 0 {
+		tags = append(tags, trace.Tag{Key: prefix + "port", Value: trace.IntValue(int64(endpoint.Port))})
+	}
+	return tags
+}
+
+// Convert converts zipkin traces to a timeline.
+//
+// Zipkin allows the client and server side of an RPC to report
+// the same span ID. In that case the server side is converted into
+// a separate span, which is a child of the client side. The client
+// side is the one found in Timeline.SpanByID.
+func Convert(traces ...Trace) (*trace.Timeline, error) {
+	var timeline trace.Timeline
+
+	traceByID := make(map[trace.TraceID]*trace.Trace)
+
+	timeline.SpanByID = make(map[trace.TraceSpanID]*trace.Span)
+	timeline.TimeRange = trace.InvalidRange
+
+	var processes trace.ProcessSet
+
+	// shared contains the server side of spans that share the ID.
+	shared := make(map[trace.TraceSpanID]*trace.Span)
+
+	include := func(node *trace.Span) {
+		tr, ok := traceByID[node.TraceID]
+		if !ok {
+			tr = &trace.Trace{
+				TraceID:   node.TraceID,
+				TimeRange: trace.InvalidRange,
+			}
+			timeline.Traces = append(timeline.Traces, tr)
+			traceByID[node.TraceID] = tr
+		}
+		tr.Spans = append(tr.Spans, node)
+		tr.TimeRange = tr.TimeRange.Expand(node.TimeRange)
+		timeline.TimeRange = timeline.TimeRange.Expand(node.TimeRange)
+	}
+
+	// Collect the halves of each span, merging duplicate reports.
+	type halves struct {
+		client, server *Span
+	}
+	var order []trace.TraceSpanID
+	spans := make(map[trace.TraceSpanID]*halves)
+	for i := range traces {
+		for k := range traces[i] {
+			span := traces[i][k]
+
+			id, err := convertTraceSpanID(span.TraceID, span.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			h, ok := spans[id]
+			if !ok {
+				h = &halves{}
+				spans[id] = h
+				order = append(order, id)
+			}
+
+			half := &h.client
+			if span.Shared || span.Kind == Server {
+				half = &h.server
+			}
+			if *half == nil {
+				*half = &span
+			} else {
+				mergeSpan(*half, &span)
+			}
+		}
+	}
+
+	// Create the nodes.
+	for _, id := range order {
+		h := spans[id]
+		if h.client == nil {
+			h.client, h.server = h.server, nil
+		}
+
+		node := &trace.Span{}
+		updateSpanContent(node, id, h.client, &processes)
+		timeline.SpanByID[id] = node
+		include(node)
+
+		if h.server != nil {
+			server := &trace.Span{}
+			updateSpanContent(server, id, h.server, &processes)
+			shared[id] = server
+			include(server)
+
+			node.Children = append(node.Children, server)
+			server.Parents = append(server.Parents, node)
+		}
+	}
+
+	// Link the parents, preferring the server side when the ID is shared,
+	// since the children are reported by the server process.
+	for _, id := range order {
+		h := spans[id]
+		if h.client.ParentID == "" {
+			continue
+		}
+
+		parentID, err := convertTraceSpanID(h.client.TraceID, h.client.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parentID == id {
+			continue
+		}
+
+		parent, ok := shared[parentID]
+		if !ok {
+			parent, ok = timeline.SpanByID[parentID]
+		}
+		if !ok {
+			parent = &trace.Span{TraceSpanID: parentID}
+			timeline.SpanByID[parentID] = parent
+		}
+
+		node := timeline.SpanByID[id]
+		parent.Children = append(parent.Children, node)
+		node.Parents = append(node.Parents, parent)
+	}
+
+	timeline.Processes = processes.List
+	timeline.Sort()
+	return &timeline, nil
+}
+
+// mergeSpan merges a duplicate report of the same span into span.
+func mergeSpan(span, other *Span) {
+	if span.Name == "" || span.Name == "unknown" {
+		span.Name = other.Name
+	}
+	if span.Kind == "" {
+		span.Kind = other.Kind
+	}
+	if span.ParentID == "" {
+		span.ParentID = other.ParentID
+	}
+	if span.LocalEndpoint == nil {
+		span.LocalEndpoint = other.LocalEndpoint
+	}
+	if span.RemoteEndpoint == nil {
+		span.RemoteEndpoint = other.RemoteEndpoint
+	}
+
+	if other.Timestamp != 0 {
+		finish := max(span.Timestamp+span.Duration, other.Timestamp+other.Duration)
+		if span.Timestamp == 0 || other.Timestamp < span.Timestamp {
+			span.Timestamp = other.Timestamp
+		}
+		span.Duration = finish - span.Timestamp
+	}
+
+	span.Annotations = append(span.Annotations, other.Annotations...)
+	sort.SliceStable(span.Annotations, func(i, k int) bool {
+		return span.Annotations[i].Timestamp < span.Annotations[k].Timestamp
+	})
+
+	if len(other.Tags) > 0 {
+		tags := make(map[string]string, len(span.Tags)+len(other.Tags))
+		for key, value := range span.Tags {
+			tags[key] = value
+		}
+		for key, value := range other.Tags {
+			tags[key] = value
+		}
+		span.Tags = tags
+	}
+
+	span.Debug = span.Debug || other.Debug
+}
+
+func updateSpanContent(node *trace.Span, id trace.TraceSpanID, span *Span, processes *trace.ProcessSet) {
+	node.TraceSpanID = id
+
+	node.Caption = span.Name
+	node.Start = span.Timestamp.Time()
+	node.Finish = node.Start + span.Duration.Time()
+
+	if span.LocalEndpoint != nil {
+		node.Service = span.LocalEndpoint.ServiceName
+		node.Process = processes.Add(span.LocalEndpoint.ServiceName, convertEndpoint("", span.LocalEndpoint))
+	}
+	node.Kind = trace.ParseSpanKind(string(span.Kind))
+	// By convention the error tag contains the error message.
+	if message, ok := span.Tags["error"]; ok {
+		node.Status.Code = trace.StatusError
+		if message != "true" {
+			node.Status.Message = message
+		}
+	}
+
+	var tags []trace.Tag
+	if span.Shared {
+		tags = append(tags, trace.Tag{Key: "shared", Value: trace.BoolValue(true)})
+	}
+	if span.Debug {
+		tags = append(tags, trace.Tag{Key: "debug", Value: trace.BoolValue(true)})
+	}
+	tags = append(tags, convertTags(span.Tags)...)
+	if span.RemoteEndpoint != nil && span.RemoteEndpoint.ServiceName != "" {
+		tags = append(tags, trace.Tag{Key: "peer.service", Value: trace.StringValue(span.RemoteEndpoint.ServiceName)})
+	}
+	tags = append(tags, convertEndpoint("peer.", span.RemoteEndpoint)...)
+
+	node.Tags = tags
+	node.Logs = convertAnnotations(span.Annotations)
+}
+
+func convertTraceSpanID(traceID TraceID, spanID SpanID) (trace.TraceSpanID, error) {
+	tid, err := trace.ParseTraceID(string(traceID))
+	if err != nil {
+		return trace.TraceSpanID{}, fmt.Errorf("invalid TraceID %q: %w", traceID, err)
+	}
+	sid, err := trace.ParseSpanID(string(spanID))
+	if err != nil {
+		return trace.TraceSpanID{}, fmt.Errorf("invalid SpanID %q: %w", spanID, err)
+	}
+
+	return trace.TraceSpanID{
+		TraceID: tid,
+		SpanID:  sid,
+	}, nil
+}
@@ -0,0 +1,86 @@
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"loov.dev/traceview/trace"
+)
+
+// File is the response of /api/v2/traces.
+//
+// The response of /api/v2/trace/{traceId}, which is a single
+// trace, can be unmarshaled into File as well.
+type File []Trace
+
+func (file *File) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	// Check whether it's a list of spans or a list of traces.
+	if len(raw) > 0 && bytes.HasPrefix(bytes.TrimSpace(raw[0]), []byte("{")) {
+		var trace Trace
+		if err := json.Unmarshal(data, &trace); err != nil {
+			return err
+		}
+		*file = File{trace}
+		return nil
+	}
+
+	var traces []Trace
+	if err := json.Unmarshal(data, &traces); err != nil {
+		return err
+	}
+	*file = traces
+	return nil
+}
+
+type Trace []Span
+
+type TraceID string
+type SpanID string
+
+type Span struct {
+	TraceID        TraceID           `json:"traceId"`
+	ParentID       SpanID            `json:"parentId,omitempty"`
+	ID             SpanID            `json:"id"`
+	Kind           Kind              `json:"kind,omitempty"`
+	Name           string            `json:"name"`
+	Timestamp      Micros            `json:"timestamp,omitempty"`
+	Duration       Micros            `json:"duration,omitempty"`
+	LocalEndpoint  *Endpoint         `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *Endpoint         `json:"remoteEndpoint,omitempty"`
+	Annotations    []Annotation      `json:"annotations,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Debug          bool              `json:"debug,omitempty"`
+	Shared         bool              `json:"shared,omitempty"`
+}
+
+type Micros int64 // in microseconds
+
+func (d Micros) Std() time.Duration { return time.Duration(d) * time.Microsecond }
+func (d Micros) Time() trace.Time   { return trace.NewTime(d.Std()) }
+
+type Kind string
+
+const (
+	Client   = Kind("CLIENT")
+	Server   = Kind("SERVER")
+	Producer = Kind("PRODUCER")
+	Consumer = Kind("CONSUMER")
+)
+
+type Endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        int    `json:"port,omitempty"`
+}
+
+type Annotation struct {
+	Timestamp Micros `json:"timestamp"`
+	Value     string `json:"value"`
+}
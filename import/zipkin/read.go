@@ -0,0 +1,29 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"loov.dev/traceview/import/internal/sniff"
+	"loov.dev/traceview/trace"
+)
+
+// Detect reports whether head looks like the beginning of a zipkin file.
+func Detect(head []byte) bool {
+	obj, ok := sniff.FirstObject(head)
+	return ok && (obj.Arrays == 1 || obj.Arrays == 2) && obj.Has("traceId")
+}
+
+// Read reads and converts a zipkin file.
+func Read(r io.Reader) (*trace.Timeline, error) {
+	var file File
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+	timeline, err := Convert(file...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert: %w", err)
+	}
+	return timeline, nil
+}
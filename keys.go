@@ -0,0 +1,204 @@
+package main
+
+import (
+	"math"
+	"slices"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/op"
+
+	"loov.dev/traceview/trace"
+)
+
+// keyZoomFactor is the zoom change for a single +/- key press.
+const keyZoomFactor = 2
+
+// handleKeys handles the keyboard shortcuts. Timeline shortcuts
+// only apply when the timeline has the keyboard focus.
+func (ui *UI) handleKeys(gtx layout.Context) {
+	focus := &ui.Viewport.clickTag
+	for {
+		ev, ok := gtx.Event(
+			key.Filter{Name: key.NameEscape},
+			key.Filter{Focus: focus, Name: key.NameReturn, Optional: key.ModShift},
+			key.Filter{Focus: focus, Name: key.NameEnter, Optional: key.ModShift},
+			key.Filter{Focus: focus, Name: key.NameSpace},
+			key.Filter{Focus: focus, Name: key.NameLeftArrow, Required: key.ModAlt},
+			key.Filter{Focus: focus, Name: key.NameRightArrow, Required: key.ModAlt},
+			key.Filter{Focus: focus, Name: key.NameLeftArrow},
+			key.Filter{Focus: focus, Name: key.NameRightArrow},
+			key.Filter{Focus: focus, Name: key.NameUpArrow},
+			key.Filter{Focus: focus, Name: key.NameDownArrow},
+			key.Filter{Focus: focus, Name: key.NameHome},
+			key.Filter{Focus: focus, Name: key.NameEnd},
+			key.Filter{Focus: focus, Name: key.NamePageUp},
+			key.Filter{Focus: focus, Name: key.NamePageDown},
+			key.Filter{Focus: focus, Name: "+", Optional: key.ModShift},
+			key.Filter{Focus: focus, Name: "=", Optional: key.ModShift},
+			key.Filter{Focus: focus, Name: "-", Optional: key.ModShift},
+			key.Filter{Focus: focus, Name: "F"},
+		)
+		if !ok {
+			break
+		}
+		e, ok := ev.(key.Event)
+		if !ok {
+			continue
+		}
+
+		if e.Name == key.NameSpace {
+			ui.Viewport.Pan.SpaceHeld = e.State == key.Press
+			gtx.Execute(op.InvalidateCmd{})
+			continue
+		}
+		if e.State != key.Press {
+			continue
+		}
+
+		switch e.Name {
+		case key.NameEscape:
+			if ui.Selected != nil {
+				ui.Selected = nil
+			} else {
+				ui.Quit = true
+			}
+		case key.NameReturn, key.NameEnter:
+			dir := 1
+			if e.Modifiers.Contain(key.ModShift) {
+				dir = -1
+			}
+			if span := ui.Search.Next(dir); span != nil {
+				ui.Reveal(span)
+			}
+
+		case key.NameLeftArrow, key.NameRightArrow, key.NameUpArrow, key.NameDownArrow:
+			if e.Modifiers.Contain(key.ModAlt) {
+				if e.Name == key.NameLeftArrow {
+					ui.Back()
+				} else {
+					ui.Forward()
+				}
+				break
+			}
+			if span := ui.navigate(e.Name); span != nil {
+				ui.Follow(span)
+			}
+
+		case key.NameHome:
+			ui.Viewport.ScrollY = 0
+		case key.NameEnd:
+			// Clamped to the content height by the timeline.
+			ui.Viewport.ScrollY = math.MaxInt32
+		case key.NamePageUp:
+			ui.Viewport.ScrollY -= ui.Viewport.SpansViewportH
+		case key.NamePageDown:
+			ui.Viewport.ScrollY += ui.Viewport.SpansViewportH
+
+		case "+", "=":
+			ui.zoomCenter(1.0 / keyZoomFactor)
+		case "-":
+			ui.zoomCenter(keyZoomFactor)
+		case "F":
+			if ui.Selected != nil {
+				ui.ZoomToSelected()
+			} else {
+				ui.ZoomToFit()
+			}
+		}
+		gtx.Execute(op.InvalidateCmd{})
+	}
+}
+
+// zoomCenter multiplies the zoom level, keeping the view center in place.
+func (ui *UI) zoomCenter(factor float64) {
+	zoom := trace.NewTime(ui.ZoomLevel.Value)
+	center := ui.Timeline.Start + ui.Viewport.ZoomOffset + zoom/2
+	ui.ZoomAround(center, 0.5, trace.Time(float64(zoom)*factor))
+}
+
+// navigate returns the span to select for the arrow key: left moves to
+// the parent, right to the first child, up and down between siblings.
+// Without a selection the first visible span is selected.
+func (ui *UI) navigate(name key.Name) *trace.Span {
+	span := ui.Selected
+	if span == nil {
+		for _, tr := range ui.Timeline.Traces {
+			for _, span := range tr.Order {
+				if span.Visible {
+					return span
+				}
+			}
+		}
+		return nil
+	}
+
+	switch name {
+	case key.NameLeftArrow:
+		// Skip the hidden parents.
+		seen := map[*trace.Span]bool{span: true}
+		for parent := span; len(parent.Parents) > 0; {
+			parent = parent.Parents[0]
+			if seen[parent] {
+				break
+			}
+			seen[parent] = true
+			if parent.Visible {
+				return parent
+			}
+		}
+	case key.NameRightArrow:
+		if children := visibleSorted(span.Children); len(children) > 0 {
+			return children[0]
+		}
+	case key.NameUpArrow, key.NameDownArrow:
+		siblings := ui.siblings(span)
+		i := slices.Index(siblings, span)
+		if name == key.NameUpArrow {
+			i--
+		} else {
+			i++
+		}
+		if 0 <= i && i < len(siblings) {
+			return siblings[i]
+		}
+	}
+	return nil
+}
+
+// siblings returns the visible children of the parent of span,
+// or the visible root spans when span doesn't have a parent.
+func (ui *UI) siblings(span *trace.Span) []*trace.Span {
+	if len(span.Parents) > 0 {
+		return visibleSorted(span.Parents[0].Children)
+	}
+	var roots []*trace.Span
+	for _, tr := range ui.Timeline.Traces {
+		for _, root := range tr.Order {
+			if len(root.Parents) == 0 && root.Visible {
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots
+}
+
+// visibleSorted returns the visible spans sorted by time.
+func visibleSorted(spans []*trace.Span) []*trace.Span {
+	var visible []*trace.Span
+	for _, span := range spans {
+		if span.Visible {
+			visible = append(visible, span)
+		}
+	}
+	slices.SortStableFunc(visible, func(a, b *trace.Span) int {
+		switch {
+		case a.TimeRange.Less(b.TimeRange):
+			return -1
+		case b.TimeRange.Less(a.TimeRange):
+			return 1
+		}
+		return 0
+	})
+	return visible
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zeebo/clingy"
+
+	"loov.dev/traceview/import/registry"
+	"loov.dev/traceview/trace"
+)
+
+// loadParams are the parameters shared by the commands that load traces.
+type loadParams struct {
+	sources    []string
+	adjustSkew bool
+}
+
+func (params *loadParams) setup(p clingy.Parameters) {
+	params.adjustSkew = p.Flag("adjust-skew", "adjust clock skew between processes", false,
+		clingy.Transform(strconv.ParseBool), clingy.Boolean).(bool)
+	params.sources = p.Arg("trace", "trace files, glob patterns or - for stdin", clingy.Repeated).([]string)
+}
+
+// timeline loads the timeline, using format when it's not nil,
+// otherwise detecting one of formats.
+func (params *loadParams) timeline(formats []registry.Format, format *registry.Format) (*trace.Timeline, error) {
+	timeline, err := loadTimeline(params.sources, formats, format)
+	if err != nil {
+		return nil, err
+	}
+	if params.adjustSkew {
+		timeline.AdjustClockSkew()
+	}
+	return timeline, nil
+}
+
+// loadTimeline loads the files matching the patterns and merges them.
+// When format is nil, the format is detected separately for each file
+// from formats, or from all the registered formats when formats is nil.
+func loadTimeline(patterns []string, formats []registry.Format, format *registry.Format) (*trace.Timeline, error) {
+	paths, err := expandPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("no trace files specified")
+	}
+
+	timelines := make([]*trace.Timeline, 0, len(paths))
+	for _, path := range paths {
+		timeline, err := loadFile(path, formats, format)
+		if err != nil {
+			return nil, err
+		}
+		timelines = append(timelines, timeline)
+	}
+
+	return trace.Merge(timelines...), nil
+}
+
+// loadFile loads a single trace file, where "-" means stdin.
+// Compressed files are decompressed transparently.
+func loadFile(path string, formats []registry.Format, format *registry.Format) (*trace.Timeline, error) {
+	source, err := openSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace %q: %w", path, err)
+	}
+	defer func() { _ = source.Close() }()
+
+	if format != nil {
+		timeline, err := format.Read(bufio.NewReader(source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s %q: %w", format.Name, path, err)
+		}
+		return timeline, nil
+	}
+
+	if formats == nil {
+		formats = registry.Formats
+	}
+	timeline, detected, err := registry.ReadIn(formats, source)
+	if err != nil {
+		if detected.Name == "" {
+			return nil, fmt.Errorf("failed to load %q: %w", path, err)
+		}
+		return nil, fmt.Errorf("failed to load %s %q: %w", detected.Name, path, err)
+	}
+	return timeline, nil
+}
+
+// openSource opens path, or stdin for "-", and decompresses the content.
+func openSource(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return registry.Decompress(os.Stdin)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	source, err := registry.Decompress(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &closers{ReadCloser: source, file: file}, nil
+}
+
+// closers closes both the decompressor and the underlying file.
+type closers struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (c *closers) Close() error {
+	return errors.Join(c.ReadCloser.Close(), c.file.Close())
+}
+
+// expandPatterns expands glob patterns, keeping plain paths and "-" as is.
+// Stdin can be read only once, so "-" must not be repeated.
+func expandPatterns(patterns []string) ([]string, error) {
+	var paths []string
+	stdin := false
+	for _, pattern := range patterns {
+		if pattern == "-" {
+			if stdin {
+				return nil, errors.New("stdin (-) specified more than once")
+			}
+			stdin = true
+		}
+		if !strings.ContainsAny(pattern, `*?[`) {
+			paths = append(paths, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
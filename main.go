@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"image/color"
 	"log"
@@ -13,6 +12,7 @@ import (
 	"github.com/zeebo/clingy"
 
 	"gioui.org/app"
+	"gioui.org/io/event"
 	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
@@ -21,12 +21,12 @@ import (
 	"gioui.org/op/paint"
 	"gioui.org/text"
 	"gioui.org/unit"
+	"gioui.org/widget"
 	"gioui.org/widget/material"
 
 	tvfont "loov.dev/traceview/font"
 
-	"loov.dev/traceview/import/jaeger"
-	"loov.dev/traceview/import/monkit"
+	"loov.dev/traceview/import/registry"
 	"loov.dev/traceview/trace"
 	"loov.dev/traceview/tui"
 )
@@ -49,8 +49,10 @@ func main() {
 		}
 
 		_, err := env.Run(ctx, func(cmds clingy.Commands) {
-			cmds.New("jaeger", "load jaeger .json trace", new(cmdJaeger))
-			cmds.New("monkit", "load monkit .json trace", new(cmdMonkit))
+			cmds.New("open", "load trace, detecting the format", new(cmdOpen))
+			for _, format := range registry.Formats {
+				cmds.New(format.Name, format.Description, &cmdFormat{format: format})
+			}
 		})
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err)
@@ -60,54 +62,40 @@ func main() {
 	}())
 }
 
-type cmdMonkit struct{ source string }
-type cmdJaeger struct{ source string }
-
-func (cmd *cmdMonkit) Setup(params clingy.Parameters) {
-	cmd.source = params.Arg("trace", "trace file").(string)
+type cmdOpen struct {
+	formats []registry.Format
+	load    loadParams
 }
 
-func (cmd *cmdJaeger) Setup(params clingy.Parameters) {
-	cmd.source = params.Arg("trace", "trace file").(string)
+func (cmd *cmdOpen) Setup(params clingy.Parameters) {
+	cmd.formats = registry.Setup(params)
+	cmd.load.setup(params)
 }
 
-func (cmd *cmdMonkit) Execute(ctx context.Context) error {
-	data, err := os.ReadFile(cmd.source)
-	if err != nil {
-		return fmt.Errorf("failed to read trace: %w", err)
-	}
-
-	var tracefile monkit.File
-	err = json.Unmarshal(data, &tracefile)
+func (cmd *cmdOpen) Execute(ctx context.Context) error {
+	timeline, err := cmd.load.timeline(cmd.formats, nil)
 	if err != nil {
-		return fmt.Errorf("failed to parse file %q: %w", cmd.source, err)
+		return err
 	}
-
-	timeline, err := monkit.Convert(tracefile)
-	if err != nil {
-		return fmt.Errorf("failed to convert monkit %q: %w", cmd.source, err)
-	}
-
 	return run(ctx, timeline)
 }
 
-func (cmd *cmdJaeger) Execute(ctx context.Context) error {
-	data, err := os.ReadFile(cmd.source)
-	if err != nil {
-		return fmt.Errorf("failed to read trace: %w", err)
-	}
+// cmdFormat loads traces in a specific format.
+type cmdFormat struct {
+	format registry.Format
+	load   loadParams
+}
 
-	var tracefile jaeger.File
-	err = json.Unmarshal(data, &tracefile)
-	if err != nil {
-		return fmt.Errorf("failed to parse file %q: %w", cmd.source, err)
-	}
+func (cmd *cmdFormat) Setup(params clingy.Parameters) {
+	cmd.format = cmd.format.Setup(params)
+	cmd.load.setup(params)
+}
 
-	timeline, err := jaeger.Convert(tracefile.Data...)
+func (cmd *cmdFormat) Execute(ctx context.Context) error {
+	timeline, err := cmd.load.timeline(nil, &cmd.format)
 	if err != nil {
-		return fmt.Errorf("failed to convert jaeger %q: %w", cmd.source, err)
+		return err
 	}
-
 	return run(ctx, timeline)
 }
 
@@ -130,10 +118,27 @@ type UI struct {
 	Theme    *material.Theme
 	Timeline *trace.Timeline
 
-	SkipSpans tui.Duration
+	Filter    Filter
 	ZoomLevel tui.Duration
 	RowHeight tui.Px
 
+	ShowCriticalPath widget.Bool
+	critical         CriticalPath
+	ColorBySelfTime  widget.Bool
+	selfTime         map[*trace.Span]trace.Time
+	ShowStats        widget.Bool
+	Stats            *StatsPanel
+
+	Search Search
+
+	ZoomToSpan  widget.Clickable
+	ZoomToTrace widget.Clickable
+
+	History History
+
+	// Quit requests closing the window.
+	Quit bool
+
 	Viewport Viewport
 	Selected *trace.Span
 	Detail   DetailPanel
@@ -145,10 +150,11 @@ func NewUI(timeline *trace.Timeline) *UI {
 	ui.Theme.Shaper = text.NewShaper(text.WithCollection(tvfont.Collection()))
 	ui.Timeline = timeline
 
-	ui.SkipSpans.SetValue(100 * time.Millisecond)
+	ui.Filter = NewFilter("duration>100ms")
 	ui.ZoomLevel.SetValue(time.Second)
 	ui.RowHeight.SetValue(12)
 
+	ui.Search = NewSearch()
 	ui.Detail = NewDetailPanel()
 
 	return ui
@@ -165,16 +171,8 @@ func (ui *UI) Run(w *app.Window) error {
 			gtx := app.NewContext(&ops, e)
 			ui.Layout(gtx)
 			e.Frame(gtx.Ops)
-
-		case key.Event:
-			switch e.Name {
-			case key.NameEscape:
-				if ui.Selected != nil {
-					ui.Selected = nil
-					w.Invalidate()
-				} else {
-					return nil
-				}
+			if ui.Quit {
+				return nil
 			}
 
 		case app.DestroyEvent:
@@ -187,19 +185,63 @@ func (ui *UI) Layout(gtx layout.Context) layout.Dimensions {
 	// Process click events early, before layout, so both
 	// the timeline and detail panel see the same selection.
 	ui.Viewport.Clicked = false
+	if !ui.Viewport.focusRequested {
+		// Start with the keyboard shortcuts enabled.
+		ui.Viewport.focusRequested = true
+		gtx.Execute(key.FocusCmd{Tag: &ui.Viewport.clickTag})
+	}
 	for {
-		ev, ok := gtx.Source.Event(pointer.Filter{
-			Target: &ui.Viewport.clickTag,
-			Kinds:  pointer.Press,
-		})
+		ev, ok := gtx.Source.Event(
+			pointer.Filter{
+				Target: &ui.Viewport.focusTag,
+				Kinds:  pointer.Press,
+			},
+		)
 		if !ok {
 			break
 		}
+		// Clicking anywhere in the timeline, including the ruler and the
+		// minimap, moves keyboard focus away from the editors.
 		if e, ok := ev.(pointer.Event); ok && e.Kind == pointer.Press {
+			gtx.Execute(key.FocusCmd{Tag: &ui.Viewport.clickTag})
+		}
+	}
+	for {
+		ev, ok := gtx.Source.Event(
+			pointer.Filter{
+				Target: &ui.Viewport.clickTag,
+				Kinds:  pointer.Press,
+			},
+			key.FocusFilter{Target: &ui.Viewport.clickTag},
+		)
+		if !ok {
+			break
+		}
+		switch e := ev.(type) {
+		case pointer.Event:
+			if e.Kind != pointer.Press {
+				break
+			}
+			if ui.Viewport.Pan.starts(e) || ui.Viewport.Brush.starts(e) {
+				break
+			}
+			if e.Buttons.Contain(pointer.ButtonQuaternary) || e.Buttons.Contain(pointer.ButtonQuinary) {
+				// Handled as back and forward by the history.
+				break
+			}
 			ui.Viewport.ClickPos = e.Position
 			ui.Viewport.Clicked = true
+		case key.FocusEvent:
+			if !e.Focus {
+				ui.Viewport.Pan.SpaceHeld = false
+			}
 		}
 	}
+	ui.handleKeys(gtx)
+
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	ui.handleHistory(gtx)
+	defer func() { ui.History.observe(ui.viewState(), gtx.Now) }()
 
 	return layout.Flex{
 		Axis: layout.Horizontal,
@@ -207,7 +249,14 @@ func (ui *UI) Layout(gtx layout.Context) layout.Dimensions {
 		layout.Rigid(ui.LayoutControls),
 		layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
 			return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+				layout.Rigid(ui.LayoutBreadcrumb),
 				layout.Flexed(1, ui.LayoutTimeline),
+				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+					if !ui.ShowStats.Value {
+						return layout.Dimensions{}
+					}
+					return ui.stats().Layout(gtx, ui.Theme)
+				}),
 				layout.Rigid(func(gtx layout.Context) layout.Dimensions {
 					ui.Detail.Span = ui.Selected
 					return ui.Detail.Layout(gtx, ui.Theme)
@@ -229,18 +278,27 @@ func (ui *UI) LayoutTimeline(gtx layout.Context) layout.Dimensions {
 
 		ZoomStart:  ui.Timeline.Start + ui.Viewport.ZoomOffset,
 		ZoomFinish: ui.Timeline.Start + ui.Viewport.ZoomOffset + trace.NewTime(ui.ZoomLevel.Value),
+
+		Critical: ui.criticalPath(),
+		SelfTime: ui.selfTimes(),
+		Matches:  ui.Search.Matched(),
+	}
+	if ui.ShowStats.Value {
+		view.Highlight = ui.stats().Selected
 	}
 
+	ui.Filter.Update(ui.Timeline)
 	for _, tr := range ui.Timeline.Traces {
 		for _, span := range tr.Order {
-			span.Visible = span.Duration().Std() > ui.SkipSpans.Value
-			if !span.Visible {
-				continue
+			if span.Visible {
+				view.Visible.Add(span)
 			}
-			view.Visible.Add(span)
 		}
 	}
 
+	defer clip.Rect{Max: gtx.Constraints.Max}.Push(gtx.Ops).Pop()
+	event.Op(gtx.Ops, &ui.Viewport.focusTag)
+
 	return layout.Flex{
 		Axis: layout.Horizontal,
 	}.Layout(gtx,
@@ -268,19 +326,120 @@ func (ui *UI) LayoutControls(gtx layout.Context) layout.Dimensions {
 	th := ui.Theme
 	return tui.SidePanel(th).Layout(gtx,
 		func(gtx layout.Context) layout.Dimensions {
-			return tui.Panel(th, "Filter").Layout(gtx,
-				tui.DurationEditor(th, &ui.SkipSpans, "Skip Spans", 0, 5*time.Second).Layout,
-			)
+			dims, jump := ui.Search.Layout(gtx, th, ui.Timeline)
+			if jump != nil {
+				ui.Reveal(jump)
+			}
+			return dims
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return ui.Filter.Layout(gtx, th)
 		},
 		func(gtx layout.Context) layout.Dimensions {
+			if ui.ZoomToSpan.Clicked(gtx) {
+				ui.ZoomToSelected()
+			}
+			if ui.ZoomToTrace.Clicked(gtx) {
+				ui.ZoomToFit()
+			}
 			return tui.Panel(th, "View").Layout(gtx,
-				tui.DurationEditor(th, &ui.ZoomLevel, "Zoom", time.Second/10, nextSecond(ui.Timeline.Duration().Std())).Layout,
+				tui.DurationEditor(th, &ui.ZoomLevel, "Zoom", time.Nanosecond, nextSecond(ui.Timeline.Duration().Std())).Layout,
 				tui.PxEditor(th, &ui.RowHeight, "Row Height", 6, 24).Layout,
+				tui.Button(th, &ui.ZoomToSpan, "Zoom to Span").Layout,
+				tui.Button(th, &ui.ZoomToTrace, "Zoom to Fit").Layout,
+			)
+		},
+		func(gtx layout.Context) layout.Dimensions {
+			return tui.Panel(th, "Analysis").Layout(gtx,
+				tui.Toggle(th, &ui.ShowCriticalPath, "Critical Path").Layout,
+				tui.Toggle(th, &ui.ColorBySelfTime, "Color by Self Time").Layout,
+				tui.Toggle(th, &ui.ShowStats, "Operation Stats").Layout,
 			)
 		},
 	)
 }
 
+// Reveal selects span and scrolls the timeline to center it.
+func (ui *UI) Reveal(span *trace.Span) {
+	ui.Selected = span
+	ui.Viewport.Reveal = span
+	ui.Viewport.RevealCenter = true
+}
+
+// Follow selects span and scrolls the timeline just enough to show it.
+func (ui *UI) Follow(span *trace.Span) {
+	ui.Selected = span
+	ui.Viewport.Reveal = span
+	ui.Viewport.RevealCenter = false
+}
+
+// ZoomAround sets the zoom level, keeping anchor at the fraction frac
+// of the view width. The zoom is limited to the timeline duration.
+func (ui *UI) ZoomAround(anchor trace.Time, frac float64, zoom trace.Time) {
+	zoom = max(1, min(zoom, ui.Timeline.Duration()))
+	ui.ZoomLevel.SetValue(zoom.Std())
+	ui.Viewport.ZoomOffset = anchor - trace.Time(frac*float64(zoom)) - ui.Timeline.Start
+}
+
+// zoomMargin is the share of the zoom window left empty on each side
+// when zooming to a span or a trace.
+const zoomMargin = 0.05
+
+// ZoomTo fits the time range in the view.
+func (ui *UI) ZoomTo(r trace.TimeRange) {
+	zoom := trace.Time(float64(r.Duration()) / (1 - 2*zoomMargin))
+	ui.ZoomAround(r.Start+r.Duration()/2, 0.5, zoom)
+}
+
+// ZoomToSelected fits the selected span in the view.
+func (ui *UI) ZoomToSelected() {
+	if ui.Selected == nil {
+		return
+	}
+	ui.ZoomTo(ui.Selected.TimeRange)
+	ui.Follow(ui.Selected)
+}
+
+// ZoomToFit fits the trace of the selected span in the view,
+// or the whole timeline when there's no selection.
+func (ui *UI) ZoomToFit() {
+	if ui.Selected != nil {
+		for _, tr := range ui.Timeline.Traces {
+			if tr.TraceID == ui.Selected.TraceID {
+				ui.ZoomTo(tr.TimeRange)
+				return
+			}
+		}
+	}
+	ui.ZoomAround(ui.Timeline.Start, 0, ui.Timeline.Duration())
+}
+
+// stats returns the stats panel, computing the statistics on first use.
+func (ui *UI) stats() *StatsPanel {
+	if ui.Stats == nil {
+		stats := NewStatsPanel(ui.Timeline)
+		ui.Stats = &stats
+	}
+	return ui.Stats
+}
+
+// selfTimes returns the self time of every span, when coloring by
+// self time is enabled.
+func (ui *UI) selfTimes() map[*trace.Span]trace.Time {
+	if !ui.ColorBySelfTime.Value {
+		return nil
+	}
+	if ui.selfTime == nil {
+		ui.selfTime = make(map[*trace.Span]trace.Time)
+		for _, tr := range ui.Timeline.Traces {
+			for _, span := range tr.Spans {
+				ui.selfTime[span] = span.SelfTime()
+			}
+		}
+	}
+	return ui.selfTime
+}
+
 func nextSecond(s time.Duration) time.Duration {
 	return time.Second * ((s + time.Second - 1) / time.Second)
 }
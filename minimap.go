@@ -0,0 +1,143 @@
+package main
+
+import (
+	"image"
+
+	"gioui.org/f32"
+	"gioui.org/io/event"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/unit"
+
+	"loov.dev/traceview/trace"
+)
+
+type minimapDrag uint8
+
+const (
+	minimapNone minimapDrag = iota
+	minimapMove
+	minimapStart
+	minimapFinish
+)
+
+// minimapEdge is the distance from the overlay edge that starts resizing.
+const minimapEdge = unit.Dp(4)
+
+// Minimap tracks dragging the visible region in the minimap.
+type Minimap struct {
+	tag  bool
+	drag minimapDrag
+
+	press       f32.Point
+	pressOffset trace.Time
+	pressScroll int
+}
+
+// handleMinimap handles clicking, dragging and scrolling the minimap.
+// Clicking outside the overlay centers the view, dragging the overlay
+// pans and dragging the overlay edges changes the zoom level.
+func (view *TimelineView) handleMinimap(gtx layout.Context, size image.Point) {
+	ui := view.UI
+	mini := &ui.Viewport.Minimap
+
+	durationToPx := float64(size.X) / float64(view.Duration())
+	pxToScroll := 1.0
+	if totalContentH := len(view.Visible.Rows) * gtx.Dp(view.RowHeight); totalContentH > 0 {
+		pxToScroll = float64(totalContentH) / float64(size.Y)
+	}
+	timeAt := func(x float32) trace.Time {
+		return view.Start + trace.Time(float64(x)/durationToPx)
+	}
+
+	x0 := float32(durationToPx * float64(view.ZoomStart-view.Start))
+	x1 := float32(durationToPx * float64(view.ZoomFinish-view.Start))
+	y0 := float32(float64(ui.Viewport.ScrollY) / pxToScroll)
+	y1 := float32(float64(ui.Viewport.ScrollY+ui.Viewport.SpansViewportH) / pxToScroll)
+	edge := float32(gtx.Dp(minimapEdge))
+
+	// Show resize cursor over the overlay edges.
+	for _, x := range []float32{x0, x1} {
+		area := clip.Rect{
+			Min: image.Point{X: int(x - edge), Y: 0},
+			Max: image.Point{X: int(x + edge), Y: size.Y},
+		}.Push(gtx.Ops)
+		pointer.CursorColResize.Add(gtx.Ops)
+		area.Pop()
+	}
+
+	event.Op(gtx.Ops, &mini.tag)
+	changed := false
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target:  &mini.tag,
+			Kinds:   pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel | pointer.Scroll,
+			ScrollY: pointer.ScrollRange{Min: -ui.Viewport.SpansViewportH, Max: ui.Viewport.SpansViewportH},
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+
+		switch e.Kind {
+		case pointer.Scroll:
+			ui.Viewport.ScrollY += int(e.Scroll.Y)
+		case pointer.Press:
+			if !e.Buttons.Contain(pointer.ButtonPrimary) {
+				continue
+			}
+			x := e.Position.X
+			switch {
+			case abs32(x-x0) <= edge:
+				mini.drag = minimapStart
+			case abs32(x-x1) <= edge:
+				mini.drag = minimapFinish
+			default:
+				// Pressing inside the overlay keeps the grab offset for dragging.
+				if x < x0 || x1 < x {
+					// Center the view around the clicked time.
+					ui.ZoomAround(timeAt(x), 0.5, view.ZoomFinish-view.ZoomStart)
+				}
+				if y := e.Position.Y; y < y0 || y1 < y {
+					// Center the view around the clicked row.
+					ui.Viewport.ScrollY = int(float64(y)*pxToScroll) - ui.Viewport.SpansViewportH/2
+				}
+				mini.drag = minimapMove
+			}
+			mini.press = e.Position
+			mini.pressOffset = ui.Viewport.ZoomOffset
+			mini.pressScroll = ui.Viewport.ScrollY
+		case pointer.Drag:
+			switch mini.drag {
+			case minimapMove:
+				delta := e.Position.Sub(mini.press)
+				ui.Viewport.ZoomOffset = mini.pressOffset + trace.Time(float64(delta.X)/durationToPx)
+				ui.Viewport.ScrollY = mini.pressScroll + int(float64(delta.Y)*pxToScroll)
+			case minimapStart:
+				start := min(timeAt(e.Position.X), view.ZoomFinish-1)
+				ui.ZoomAround(view.ZoomFinish, 1, view.ZoomFinish-start)
+			case minimapFinish:
+				finish := max(timeAt(e.Position.X), view.ZoomStart+1)
+				ui.ZoomAround(view.ZoomStart, 0, finish-view.ZoomStart)
+			}
+		case pointer.Release, pointer.Cancel:
+			mini.drag = minimapNone
+		}
+		changed = true
+	}
+
+	if changed {
+		view.zoomChanged(gtx)
+	}
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
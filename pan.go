@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"gioui.org/f32"
+	"gioui.org/io/pointer"
+	"gioui.org/layout"
+	"gioui.org/op"
+
+	"loov.dev/traceview/trace"
+)
+
+const (
+	// panDecay is the time constant of the kinetic scroll slowdown.
+	panDecay = 325 * time.Millisecond
+	// panMinVelocity stops the kinetic scroll, in px per second.
+	panMinVelocity = 20
+	// panIdle discards the velocity when the pointer stopped before release.
+	panIdle = 50 * time.Millisecond
+)
+
+// Pan tracks dragging the timeline with the middle button or space+drag.
+type Pan struct {
+	tag bool
+
+	// SpaceHeld enables panning with the primary button.
+	SpaceHeld bool
+
+	dragging bool
+	last     f32.Point
+	lastTime time.Duration
+
+	// velocity is in px per second.
+	velocity  f32.Point
+	inertia   bool
+	lastFrame time.Time
+
+	// remainder accumulates the fractional vertical scroll.
+	remainder float32
+}
+
+// Active reports whether the pointer is dragging the timeline.
+func (pan *Pan) Active() bool { return pan.dragging }
+
+// starts reports whether the press starts panning instead of selecting.
+func (pan *Pan) starts(e pointer.Event) bool {
+	return e.Buttons.Contain(pointer.ButtonTertiary) ||
+		pan.SpaceHeld && e.Buttons.Contain(pointer.ButtonPrimary)
+}
+
+// update handles the pointer events and the kinetic scroll,
+// returning the movement in px.
+func (pan *Pan) update(gtx layout.Context) f32.Point {
+	var delta f32.Point
+	for {
+		ev, ok := gtx.Event(pointer.Filter{
+			Target: &pan.tag,
+			Kinds:  pointer.Press | pointer.Drag | pointer.Release | pointer.Cancel,
+		})
+		if !ok {
+			break
+		}
+		e, ok := ev.(pointer.Event)
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case pointer.Press:
+			if !pan.starts(e) {
+				continue
+			}
+			pan.dragging = true
+			pan.inertia = false
+			pan.velocity = f32.Point{}
+			pan.last, pan.lastTime = e.Position, e.Time
+			gtx.Execute(pointer.GrabCmd{Tag: &pan.tag, ID: e.PointerID})
+		case pointer.Drag:
+			if !pan.dragging {
+				continue
+			}
+			d := e.Position.Sub(pan.last)
+			delta = delta.Add(d)
+			if dt := (e.Time - pan.lastTime).Seconds(); dt > 0 {
+				v := d.Mul(float32(1 / dt))
+				pan.velocity = pan.velocity.Mul(0.2).Add(v.Mul(0.8))
+			}
+			pan.last, pan.lastTime = e.Position, e.Time
+		case pointer.Release, pointer.Cancel:
+			if !pan.dragging {
+				continue
+			}
+			pan.dragging = false
+			if e.Kind == pointer.Cancel || e.Time-pan.lastTime > panIdle {
+				pan.velocity = f32.Point{}
+			}
+			pan.inertia = length(pan.velocity) > panMinVelocity
+			pan.lastFrame = gtx.Now
+		}
+	}
+
+	if pan.inertia && !pan.dragging {
+		dt := gtx.Now.Sub(pan.lastFrame)
+		pan.lastFrame = gtx.Now
+		delta = delta.Add(pan.velocity.Mul(float32(dt.Seconds())))
+		pan.velocity = pan.velocity.Mul(float32(math.Exp(-dt.Seconds() / panDecay.Seconds())))
+		if length(pan.velocity) < panMinVelocity {
+			pan.inertia = false
+		} else {
+			gtx.Execute(op.InvalidateCmd{})
+		}
+	}
+
+	return delta
+}
+
+// apply moves the viewport by the pan delta.
+func (pan *Pan) apply(viewport *Viewport, delta f32.Point, pxToDuration float64) {
+	pan.remainder -= delta.Y
+	dy := int(pan.remainder)
+	pan.remainder -= float32(dy)
+	viewport.ScrollY += dy
+	viewport.ZoomOffset -= trace.Time(float64(delta.X) * pxToDuration)
+}
+
+func length(p f32.Point) float32 {
+	return float32(math.Hypot(float64(p.X), float64(p.Y)))
+}
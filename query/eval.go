@@ -0,0 +1,232 @@
+package query
+
+import (
+	"cmp"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"loov.dev/traceview/trace"
+)
+
+// Expr is a parsed query.
+type Expr interface {
+	Match(span *trace.Span) bool
+}
+
+// All matches every span.
+type All struct{}
+
+func (All) Match(span *trace.Span) bool { return true }
+
+type And struct{ Left, Right Expr }
+
+func (e And) Match(span *trace.Span) bool { return e.Left.Match(span) && e.Right.Match(span) }
+
+type Or struct{ Left, Right Expr }
+
+func (e Or) Match(span *trace.Span) bool { return e.Left.Match(span) || e.Right.Match(span) }
+
+type Not struct{ Expr Expr }
+
+func (e Not) Match(span *trace.Span) bool { return !e.Expr.Match(span) }
+
+// Compare is the operator and the value of a comparison.
+type Compare struct {
+	Op     string
+	Value  string
+	Regexp *regexp.Regexp
+}
+
+// result converts the three-way comparison result to the outcome of the operator.
+func (c Compare) result(r int) bool {
+	switch c.Op {
+	case "=":
+		return r == 0
+	case "!=":
+		return r != 0
+	case "<":
+		return r < 0
+	case "<=":
+		return r <= 0
+	case ">":
+		return r > 0
+	case ">=":
+		return r >= 0
+	}
+	return false
+}
+
+// MatchText compares a string.
+func (c Compare) MatchText(s string) bool {
+	switch c.Op {
+	case "~":
+		return c.Regexp.MatchString(s)
+	case "!~":
+		return !c.Regexp.MatchString(s)
+	}
+	return c.result(strings.Compare(s, c.Value))
+}
+
+// MatchValue compares a typed value. When the query value is a number,
+// numbers and numeric strings are compared numerically, otherwise
+// the value is compared as a string.
+func (c Compare) MatchValue(v trace.Value) bool {
+	if c.Regexp != nil {
+		return c.MatchText(v.String())
+	}
+	if literal, ok := parseNumber(c.Value); ok {
+		if !v.IsNumber() {
+			number, ok := parseNumber(v.String())
+			if !ok {
+				return c.Op == "!="
+			}
+			v = number
+		}
+		return c.result(v.Compare(literal))
+	}
+	return c.MatchText(v.String())
+}
+
+func parseNumber(s string) (trace.Value, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return trace.IntValue(n), true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return trace.FloatValue(f), true
+	}
+	return trace.Value{}, false
+}
+
+// TextField compares caption, service, kind or status.
+type TextField struct {
+	Field string
+	Compare
+}
+
+func (e TextField) Match(span *trace.Span) bool {
+	switch e.Field {
+	case "caption":
+		return e.MatchText(span.Caption)
+	case "service":
+		return e.MatchText(span.Service)
+	case "kind":
+		return e.MatchText(span.Kind.String())
+	case "status":
+		return e.MatchText(span.Status.Code.String())
+	}
+	return false
+}
+
+// TimeField compares duration or self time.
+type TimeField struct {
+	Field string
+	Op    string
+	Value trace.Time
+}
+
+func (e TimeField) Match(span *trace.Span) bool {
+	t := span.Duration()
+	if e.Field == "self" {
+		t = span.SelfTime()
+	}
+	return Compare{Op: e.Op}.result(cmp.Compare(t, e.Value))
+}
+
+// DepthField compares the number of ancestors of the span.
+type DepthField struct {
+	Op    string
+	Value int
+}
+
+func (e DepthField) Match(span *trace.Span) bool {
+	return Compare{Op: e.Op}.result(cmp.Compare(depth(span), e.Value))
+}
+
+// depth follows the first parent up to the root.
+func depth(span *trace.Span) int {
+	seen := map[*trace.Span]bool{span: true}
+	n := 0
+	for len(span.Parents) > 0 {
+		span = span.Parents[0]
+		if seen[span] {
+			break
+		}
+		seen[span] = true
+		n++
+	}
+	return n
+}
+
+// IDField compares the trace or span ID.
+type IDField struct {
+	Field string
+	Compare
+}
+
+func (e IDField) Match(span *trace.Span) bool {
+	if e.Field == "trace" {
+		return e.MatchText(span.TraceID.String())
+	}
+	return e.MatchText(span.SpanID.String())
+}
+
+// TagCompare compares the span tag or process tag with the specified key.
+type TagCompare struct {
+	Key string
+	Compare
+}
+
+func (e TagCompare) Match(span *trace.Span) bool {
+	return anyTag(span, e.Key, e.MatchValue)
+}
+
+// TagExists matches spans that have the tag.
+type TagExists struct{ Key string }
+
+func (e TagExists) Match(span *trace.Span) bool {
+	return anyTag(span, e.Key, func(trace.Value) bool { return true })
+}
+
+// LogCompare compares the log fields with the specified key.
+type LogCompare struct {
+	Key string
+	Compare
+}
+
+func (e LogCompare) Match(span *trace.Span) bool {
+	for _, log := range span.Logs {
+		if findTag(log.Fields, e.Key, e.MatchValue) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogExists matches spans that have a log with the field.
+type LogExists struct{ Key string }
+
+func (e LogExists) Match(span *trace.Span) bool {
+	for _, log := range span.Logs {
+		if findTag(log.Fields, e.Key, func(trace.Value) bool { return true }) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyTag(span *trace.Span, key string, match func(trace.Value) bool) bool {
+	if findTag(span.Tags, key, match) {
+		return true
+	}
+	return span.Process != nil && findTag(span.Process.Tags, key, match)
+}
+
+func findTag(tags []trace.Tag, key string, match func(trace.Value) bool) bool {
+	for _, tag := range tags {
+		if tag.Key == key && match(tag.Value) {
+			return true
+		}
+	}
+	return false
+}
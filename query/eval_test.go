@@ -0,0 +1,57 @@
+package query
+
+import (
+	"regexp"
+	"testing"
+
+	"loov.dev/traceview/trace"
+)
+
+func TestMatchValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    string
+		query string
+		value trace.Value
+		exp   bool
+	}{
+		{name: "int equal", op: "=", query: "500", value: trace.IntValue(500), exp: true},
+		{name: "float equal int", op: "=", query: "500", value: trace.FloatValue(500), exp: true},
+		{name: "int equal float", op: "=", query: "1.5", value: trace.IntValue(1), exp: false},
+		{name: "numeric greater", op: ">", query: "500", value: trace.IntValue(1000), exp: true},
+		{name: "numeric not lexical", op: "<", query: "500", value: trace.IntValue(60), exp: true},
+		{name: "numeric string equal", op: "=", query: "500", value: trace.StringValue("500"), exp: true},
+		{name: "numeric string greater", op: ">=", query: "500", value: trace.StringValue("1000"), exp: true},
+		{name: "numeric string less", op: "<", query: "500", value: trace.StringValue("60"), exp: true},
+		{name: "float string", op: ">", query: "0.5", value: trace.StringValue("0.75"), exp: true},
+		{name: "not a number equal", op: "=", query: "500", value: trace.StringValue("error"), exp: false},
+		{name: "not a number not equal", op: "!=", query: "500", value: trace.StringValue("error"), exp: true},
+		{name: "not a number less", op: "<", query: "500", value: trace.StringValue("error"), exp: false},
+		{name: "not a number greater", op: ">", query: "500", value: trace.StringValue("error"), exp: false},
+		{name: "bool not a number", op: "!=", query: "1", value: trace.BoolValue(true), exp: true},
+		{name: "string equal", op: "=", query: "GET", value: trace.StringValue("GET"), exp: true},
+		{name: "string not equal", op: "!=", query: "GET", value: trace.StringValue("POST"), exp: true},
+		{name: "string less", op: "<", query: "b", value: trace.StringValue("a"), exp: true},
+		{name: "bool as string", op: "=", query: "true", value: trace.BoolValue(true), exp: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Compare{Op: test.op, Value: test.query}
+			if got := c.MatchValue(test.value); got != test.exp {
+				t.Fatalf("%v %s %q: got %v, expected %v", test.value, test.op, test.query, got, test.exp)
+			}
+		})
+	}
+}
+
+func TestMatchValueRegexp(t *testing.T) {
+	c := Compare{Op: "~", Value: "^5", Regexp: regexp.MustCompile("^5")}
+	if !c.MatchValue(trace.IntValue(503)) {
+		t.Error("expected 503 to match ^5")
+	}
+	c.Op = "!~"
+	if !c.MatchValue(trace.StringValue("200")) {
+		t.Error("expected 200 to not match ^5")
+	}
+}
@@ -0,0 +1,327 @@
+// Package query implements a small language for filtering spans.
+//
+// A query consists of comparisons joined with AND, OR and NOT, for example:
+//
+//	service=api AND duration>50ms AND tag:http.status_code>=500 AND NOT caption~"health"
+//
+// Comparisons have the form field op value, where op is one of
+// =, !=, <, <=, >, >=, ~ (regular expression match) or !~.
+// Terms without an operator are joined with AND.
+//
+// The fields are caption, service, kind, status, duration, self, depth,
+// trace and span. Tags are referenced with tag:key, which also matches
+// process tags, and log fields with log:key. A tag or log field without
+// an operator checks whether it exists.
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"loov.dev/traceview/trace"
+)
+
+// Error describes a syntax error in a query.
+type Error struct {
+	Offset  int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("at %d: %s", err.Offset+1, err.Message)
+}
+
+// Parse parses the query, an empty query matches all spans.
+func Parse(s string) (Expr, error) {
+	p := &parser{tokens: tokenize(s), end: len(s)}
+	if p.peek().kind == tokenEOF {
+		return All{}, nil
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	return expr, nil
+}
+
+type tokenKind byte
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOp
+	tokenOpen
+	tokenClose
+	tokenInvalid
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+// keyword reports whether the token is the specified keyword.
+func (tok token) keyword(name string) bool {
+	return tok.kind == tokenWord && strings.EqualFold(tok.text, name)
+}
+
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+func tokenize(s string) []token {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, text: "(", offset: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, text: ")", offset: i})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				tokens = append(tokens, token{kind: tokenInvalid, text: s[i:], offset: i})
+				return tokens
+			}
+			tokens = append(tokens, token{kind: tokenString, text: s[i : end+1], offset: i})
+			i = end + 1
+		case isOperator(c):
+			op := string(c)
+			for _, candidate := range operators {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, offset: i})
+			i += len(op)
+		default:
+			end := i
+			for end < len(s) && isWord(s[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: s[i:end], offset: i})
+			i = end
+		}
+	}
+	return tokens
+}
+
+func isOperator(c byte) bool { return strings.IndexByte("=!<>~", c) >= 0 }
+
+func isWord(c byte) bool {
+	return !isOperator(c) && strings.IndexByte(" \t\n\r()\"", c) < 0
+}
+
+type parser struct {
+	tokens []token
+	end    int
+}
+
+func (p *parser) peek() token {
+	if len(p.tokens) == 0 {
+		return token{kind: tokenEOF, offset: p.end}
+	}
+	return p.tokens[0]
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if len(p.tokens) > 0 {
+		p.tokens = p.tokens[1:]
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, format string, args ...any) error {
+	return &Error{Offset: tok.offset, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind == tokenEOF || tok.kind == tokenClose || tok.keyword("OR") {
+			return left, nil
+		}
+		if tok.keyword("AND") {
+			p.next()
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{left, right}
+	}
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.next()
+	switch {
+	case tok.keyword("NOT"):
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{expr}, nil
+	case tok.kind == tokenOpen:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenClose {
+			return nil, p.errorf(closing, "missing )")
+		}
+		return expr, nil
+	case tok.kind == tokenWord:
+		return p.parseComparison(tok)
+	case tok.kind == tokenEOF:
+		return nil, p.errorf(tok, "unexpected end of query")
+	case tok.kind == tokenInvalid:
+		return nil, p.errorf(tok, "unterminated string")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+func (p *parser) parseComparison(field token) (Expr, error) {
+	name, key, hasKey := strings.Cut(field.text, ":")
+	name = strings.ToLower(name)
+	if hasKey && key == "" {
+		return nil, p.errorf(field, "missing key in %q", field.text)
+	}
+
+	if p.peek().kind != tokenOp {
+		switch {
+		case name == "tag" && hasKey:
+			return TagExists{Key: key}, nil
+		case name == "log" && hasKey:
+			return LogExists{Key: key}, nil
+		}
+		return nil, p.errorf(p.peek(), "missing operator after %q", field.text)
+	}
+	op := p.next()
+
+	valueToken := p.next()
+	var value string
+	switch valueToken.kind {
+	case tokenWord:
+		value = valueToken.text
+	case tokenString:
+		unquoted, err := strconv.Unquote(valueToken.text)
+		if err != nil {
+			return nil, p.errorf(valueToken, "invalid string %s", valueToken.text)
+		}
+		value = unquoted
+	case tokenInvalid:
+		return nil, p.errorf(valueToken, "unterminated string")
+	default:
+		return nil, p.errorf(valueToken, "missing value after %q", field.text+op.text)
+	}
+
+	var rx *regexp.Regexp
+	if op.text == "~" || op.text == "!~" {
+		var err error
+		rx, err = regexp.Compile(value)
+		if err != nil {
+			return nil, p.errorf(valueToken, "invalid regexp: %v", err)
+		}
+	}
+	cmp := Compare{Op: op.text, Value: value, Regexp: rx}
+
+	if hasKey {
+		switch name {
+		case "tag":
+			return TagCompare{Key: key, Compare: cmp}, nil
+		case "log":
+			return LogCompare{Key: key, Compare: cmp}, nil
+		}
+		return nil, p.errorf(field, "unknown field %q", field.text)
+	}
+
+	switch name {
+	case "caption", "service":
+		return TextField{Field: name, Compare: cmp}, nil
+	case "kind", "status":
+		if rx == nil {
+			cmp.Value = strings.ToLower(cmp.Value)
+		}
+		return TextField{Field: name, Compare: cmp}, nil
+	case "duration", "self":
+		if rx != nil {
+			return nil, p.errorf(op, "%s does not support %s", name, op.text)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, p.errorf(valueToken, "invalid duration %q", value)
+		}
+		return TimeField{Field: name, Op: op.text, Value: trace.NewTime(d)}, nil
+	case "depth":
+		if rx != nil {
+			return nil, p.errorf(op, "depth does not support %s", op.text)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, p.errorf(valueToken, "invalid depth %q", value)
+		}
+		return DepthField{Op: op.text, Value: n}, nil
+	case "trace", "span":
+		if rx == nil && op.text != "=" && op.text != "!=" {
+			return nil, p.errorf(op, "%s does not support %s", name, op.text)
+		}
+		if rx == nil {
+			id, err := normalizeID(name, value)
+			if err != nil {
+				return nil, p.errorf(valueToken, "invalid %s id %q", name, value)
+			}
+			cmp.Value = id
+		}
+		return IDField{Field: name, Compare: cmp}, nil
+	}
+	return nil, p.errorf(field, "unknown field %q", field.text)
+}
+
+// normalizeID formats the id the same way as the span ids are formatted.
+func normalizeID(field, value string) (string, error) {
+	if field == "trace" {
+		id, err := trace.ParseTraceID(value)
+		return id.String(), err
+	}
+	id, err := trace.ParseSpanID(value)
+	return id.String(), err
+}
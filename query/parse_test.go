@@ -0,0 +1,138 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"loov.dev/traceview/trace"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		exp   Expr
+	}{
+		{query: "", exp: All{}},
+		{query: "  ", exp: All{}},
+		{
+			query: `service=api AND duration>50ms`,
+			exp: And{
+				Left:  TextField{Field: "service", Compare: Compare{Op: "=", Value: "api"}},
+				Right: TimeField{Field: "duration", Op: ">", Value: trace.NewTime(50 * time.Millisecond)},
+			},
+		},
+		{
+			query: `caption=a caption=b OR NOT service=c`,
+			exp: Or{
+				Left: And{
+					Left:  TextField{Field: "caption", Compare: Compare{Op: "=", Value: "a"}},
+					Right: TextField{Field: "caption", Compare: Compare{Op: "=", Value: "b"}},
+				},
+				Right: Not{Expr: TextField{Field: "service", Compare: Compare{Op: "=", Value: "c"}}},
+			},
+		},
+		{
+			query: `(caption=a OR caption=b) AND self<=1s`,
+			exp: And{
+				Left: Or{
+					Left:  TextField{Field: "caption", Compare: Compare{Op: "=", Value: "a"}},
+					Right: TextField{Field: "caption", Compare: Compare{Op: "=", Value: "b"}},
+				},
+				Right: TimeField{Field: "self", Op: "<=", Value: trace.NewTime(time.Second)},
+			},
+		},
+		{query: `tag:http.status_code>=500`, exp: TagCompare{Key: "http.status_code", Compare: Compare{Op: ">=", Value: "500"}}},
+		{query: `tag:error`, exp: TagExists{Key: "error"}},
+		{query: `log:event="cache miss"`, exp: LogCompare{Key: "event", Compare: Compare{Op: "=", Value: "cache miss"}}},
+		{query: `log:event`, exp: LogExists{Key: "event"}},
+		{query: `depth<2`, exp: DepthField{Op: "<", Value: 2}},
+		{query: `trace=ab`, exp: IDField{Field: "trace", Compare: Compare{Op: "=", Value: "00000000000000ab"}}},
+		{query: `span!=cd`, exp: IDField{Field: "span", Compare: Compare{Op: "!=", Value: "00000000000000cd"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := Parse(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Fatalf("got %#v, expected %#v", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestParseExample(t *testing.T) {
+	expr, err := Parse(`service=api AND duration>50ms AND tag:http.status_code>=500 AND NOT caption~"health"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span := func(service, caption string, duration time.Duration, status trace.Value) *trace.Span {
+		return &trace.Span{
+			Service:   service,
+			Caption:   caption,
+			TimeRange: trace.TimeRange{Finish: trace.NewTime(duration)},
+			Tags:      []trace.Tag{{Key: "http.status_code", Value: status}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		span *trace.Span
+		exp  bool
+	}{
+		{name: "match", span: span("api", "GET /users", time.Second, trace.IntValue(503)), exp: true},
+		{name: "string status", span: span("api", "GET /users", time.Second, trace.StringValue("500")), exp: true},
+		{name: "other service", span: span("db", "GET /users", time.Second, trace.IntValue(503)), exp: false},
+		{name: "too short", span: span("api", "GET /users", 50*time.Millisecond, trace.IntValue(503)), exp: false},
+		{name: "success", span: span("api", "GET /users", time.Second, trace.IntValue(200)), exp: false},
+		{name: "health check", span: span("api", "GET /healthz", time.Second, trace.IntValue(503)), exp: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := expr.Match(test.span); got != test.exp {
+				t.Fatalf("got %v, expected %v", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		query string
+		err   string
+	}{
+		{query: `(caption=a`, err: `at 11: missing )`},
+		{query: `caption=a)`, err: `at 10: unexpected ")"`},
+		{query: `caption=`, err: `at 9: missing value after "caption="`},
+		{query: `caption>=`, err: `at 10: missing value after "caption>="`},
+		{query: `caption a`, err: `at 9: missing operator after "caption"`},
+		{query: `foo=1`, err: `at 1: unknown field "foo"`},
+		{query: `duration>abc`, err: `at 10: invalid duration "abc"`},
+		{query: `depth>x`, err: `at 7: invalid depth "x"`},
+		{query: `trace=zz`, err: `at 7: invalid trace id "zz"`},
+		{query: `caption="abc`, err: `at 9: unterminated string`},
+		{query: `caption~"("`, err: "at 9: invalid regexp: error parsing regexp: missing closing ): `(`"},
+		{query: `tag:`, err: `at 1: missing key in "tag:"`},
+		{query: `NOT`, err: `at 4: unexpected end of query`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			_, err := Parse(test.query)
+			if err == nil {
+				t.Fatalf("expected error %q", test.err)
+			}
+			if _, ok := err.(*Error); !ok {
+				t.Errorf("got %T, expected *Error", err)
+			}
+			if err.Error() != test.err {
+				t.Fatalf("got %q, expected %q", err.Error(), test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"regexp"
+	"strings"
+
+	"gioui.org/io/key"
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/traceview/trace"
+	"loov.dev/traceview/tui"
+)
+
+// Search finds spans by caption, service, tags and log fields.
+type Search struct {
+	Editor widget.Editor
+	Regex  widget.Bool
+
+	// Matches lists the matching spans in render order.
+	Matches []*trace.Span
+	// Current is the index of the last jumped to match, -1 when none.
+	Current int
+	// Err is the regular expression compilation error.
+	Err error
+
+	matched map[*trace.Span]bool
+	query   string
+	regex   bool
+}
+
+func NewSearch() Search {
+	return Search{
+		Editor:  widget.Editor{SingleLine: true},
+		Current: -1,
+	}
+}
+
+// Matched returns the set of matching spans, nil when there's no query.
+func (search *Search) Matched() map[*trace.Span]bool {
+	return search.matched
+}
+
+// update recomputes the matches when the query has changed.
+func (search *Search) update(timeline *trace.Timeline) {
+	query := search.Editor.Text()
+	if query == search.query && search.Regex.Value == search.regex {
+		return
+	}
+	search.query, search.regex = query, search.Regex.Value
+
+	search.Matches = nil
+	search.matched = nil
+	search.Current = -1
+	search.Err = nil
+	if query == "" {
+		return
+	}
+
+	var match func(s string) bool
+	if search.regex {
+		rx, err := regexp.Compile(query)
+		if err != nil {
+			search.Err = err
+			return
+		}
+		match = rx.MatchString
+	} else {
+		lower := strings.ToLower(query)
+		match = func(s string) bool {
+			return strings.Contains(strings.ToLower(s), lower)
+		}
+	}
+
+	search.matched = make(map[*trace.Span]bool)
+	for _, tr := range timeline.Traces {
+		for _, span := range tr.Order {
+			if span.Match(match) {
+				search.Matches = append(search.Matches, span)
+				search.matched[span] = true
+			}
+		}
+	}
+}
+
+// Next moves to the next visible match in direction dir and returns it,
+// nil when there are no visible matches.
+func (search *Search) Next(dir int) *trace.Span {
+	n := len(search.Matches)
+	if n == 0 {
+		return nil
+	}
+	at := search.Current
+	if at < 0 && dir < 0 {
+		at = 0
+	}
+	for range n {
+		at = ((at+dir)%n + n) % n
+		if search.Matches[at].Visible {
+			search.Current = at
+			return search.Matches[at]
+		}
+	}
+	return nil
+}
+
+// Layout handles the query editor and returns the span to jump to,
+// when the user pressed Enter or Shift+Enter.
+func (search *Search) Layout(gtx layout.Context, th *material.Theme, timeline *trace.Timeline) (layout.Dimensions, *trace.Span) {
+	var jump *trace.Span
+	// Intercept Enter before the editor sees it.
+	for {
+		ev, ok := gtx.Event(
+			key.Filter{Focus: &search.Editor, Name: key.NameReturn, Optional: key.ModShift},
+			key.Filter{Focus: &search.Editor, Name: key.NameEnter, Optional: key.ModShift},
+		)
+		if !ok {
+			break
+		}
+		if e, ok := ev.(key.Event); ok && e.State == key.Press {
+			search.update(timeline)
+			if e.Modifiers.Contain(key.ModShift) {
+				jump = search.Next(-1)
+			} else {
+				jump = search.Next(1)
+			}
+		}
+	}
+
+	dims := tui.Panel(th, "Search").Layout(gtx,
+		tui.TextEditor(th, &search.Editor, "caption, tag or log").Layout,
+		tui.Toggle(th, &search.Regex, "Regex").Layout,
+		func(gtx layout.Context) layout.Dimensions {
+			search.update(timeline)
+			return search.layoutStatus(gtx, th)
+		},
+	)
+	return dims, jump
+}
+
+func (search *Search) layoutStatus(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	var status string
+	switch {
+	case search.Err != nil:
+		return errorLabel(th, search.Err).Layout(gtx)
+	case search.query == "":
+		return layout.Dimensions{}
+	case search.Current >= 0:
+		status = fmt.Sprintf("%d / %d matches", search.Current+1, len(search.Matches))
+	default:
+		status = fmt.Sprintf("%d matches", len(search.Matches))
+	}
+
+	lbl := material.Body2(th, status)
+	lbl.TextSize *= 0.8
+	lbl.Color = color.NRGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}
+	return lbl.Layout(gtx)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"sort"
+	"strconv"
+
+	"gioui.org/layout"
+	"gioui.org/op/clip"
+	"gioui.org/op/paint"
+	"gioui.org/text"
+	"gioui.org/unit"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+
+	"loov.dev/traceview/trace"
+)
+
+// statsColumn describes a column in the stats panel.
+type statsColumn struct {
+	Title string
+	Value func(stats *trace.OperationStats) string
+	Less  func(a, b *trace.OperationStats) bool
+}
+
+func durationColumn(title string, value func(stats *trace.OperationStats) trace.Time) statsColumn {
+	return statsColumn{
+		Title: title,
+		Value: func(stats *trace.OperationStats) string { return formatDuration(value(stats).Std()) },
+		Less:  func(a, b *trace.OperationStats) bool { return value(a) < value(b) },
+	}
+}
+
+var statsColumns = []statsColumn{
+	{
+		Title: "Operation",
+		Value: func(stats *trace.OperationStats) string {
+			if stats.Service == "" {
+				return stats.Caption
+			}
+			return stats.Service + ": " + stats.Caption
+		},
+		Less: func(a, b *trace.OperationStats) bool {
+			if a.Service == b.Service {
+				return a.Caption < b.Caption
+			}
+			return a.Service < b.Service
+		},
+	},
+	{
+		Title: "Count",
+		Value: func(stats *trace.OperationStats) string { return strconv.Itoa(stats.Count) },
+		Less:  func(a, b *trace.OperationStats) bool { return a.Count < b.Count },
+	},
+	{
+		Title: "Errors",
+		Value: func(stats *trace.OperationStats) string { return strconv.Itoa(stats.Errors) },
+		Less:  func(a, b *trace.OperationStats) bool { return a.Errors < b.Errors },
+	},
+	durationColumn("Total", func(stats *trace.OperationStats) trace.Time { return stats.Total }),
+	durationColumn("Self", func(stats *trace.OperationStats) trace.Time { return stats.Self }),
+	durationColumn("Mean", func(stats *trace.OperationStats) trace.Time { return stats.Mean }),
+	durationColumn("P50", func(stats *trace.OperationStats) trace.Time { return stats.P50 }),
+	durationColumn("P95", func(stats *trace.OperationStats) trace.Time { return stats.P95 }),
+	durationColumn("P99", func(stats *trace.OperationStats) trace.Time { return stats.P99 }),
+	durationColumn("Max", func(stats *trace.OperationStats) trace.Time { return stats.Max }),
+}
+
+// statsSortTotal is the column used for sorting by default.
+const statsSortTotal = 3
+
+// StatsPanel displays aggregate statistics of operations.
+// Clicking a row selects the operation, clicking a header sorts by the column.
+type StatsPanel struct {
+	Operations []*trace.OperationStats
+	Selected   *trace.OperationStats
+
+	SortBy     int
+	Descending bool
+
+	headers []widget.Clickable
+	rows    []widget.Clickable
+	list    widget.List
+}
+
+func NewStatsPanel(timeline *trace.Timeline) StatsPanel {
+	return StatsPanel{
+		Operations: timeline.Operations(),
+		SortBy:     statsSortTotal,
+		Descending: true,
+
+		headers: make([]widget.Clickable, len(statsColumns)),
+		list:    widget.List{List: layout.List{Axis: layout.Vertical}},
+	}
+}
+
+const statsPanelHeight = unit.Dp(200)
+
+func (panel *StatsPanel) Layout(gtx layout.Context, th *material.Theme) layout.Dimensions {
+	for i := range panel.headers {
+		if panel.headers[i].Clicked(gtx) {
+			if panel.SortBy == i {
+				panel.Descending = !panel.Descending
+			} else {
+				panel.SortBy = i
+				panel.Descending = i != 0
+			}
+			panel.sort()
+		}
+	}
+	if len(panel.rows) != len(panel.Operations) {
+		panel.rows = make([]widget.Clickable, len(panel.Operations))
+	}
+	for i := range panel.rows {
+		if panel.rows[i].Clicked(gtx) {
+			if panel.Selected == panel.Operations[i] {
+				panel.Selected = nil
+			} else {
+				panel.Selected = panel.Operations[i]
+			}
+		}
+	}
+
+	bg := color.NRGBA{R: 0x20, G: 0x20, B: 0x28, A: 0xFF}
+	borderColor := color.NRGBA{R: 0x50, G: 0x50, B: 0x58, A: 0xFF}
+
+	height := gtx.Dp(statsPanelHeight)
+	gtx.Constraints.Min.Y = height
+	gtx.Constraints.Max.Y = height
+
+	return layout.Stack{}.Layout(gtx,
+		layout.Expanded(func(gtx layout.Context) layout.Dimensions {
+			size := image.Point{X: gtx.Constraints.Max.X, Y: height}
+			paint.FillShape(gtx.Ops, borderColor, clip.Rect{Max: image.Point{X: size.X, Y: 1}}.Op())
+			paint.FillShape(gtx.Ops, bg, clip.Rect{Min: image.Point{Y: 1}, Max: size}.Op())
+			return layout.Dimensions{Size: size}
+		}),
+		layout.Stacked(func(gtx layout.Context) layout.Dimensions {
+			return layout.UniformInset(unit.Dp(6)).Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+				return layout.Flex{Axis: layout.Vertical}.Layout(gtx,
+					layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+						return panel.layoutRow(gtx, func(gtx layout.Context, i int) layout.Dimensions {
+							return panel.headers[i].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								title := statsColumns[i].Title
+								if i == panel.SortBy {
+									if panel.Descending {
+										title += " ▼"
+									} else {
+										title += " ▲"
+									}
+								}
+								return panel.label(gtx, th, i, title, color.NRGBA{R: 0xB0, G: 0xB0, B: 0xB4, A: 0xFF})
+							})
+						})
+					}),
+					layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+						return material.List(th, &panel.list).Layout(gtx, len(panel.Operations), func(gtx layout.Context, row int) layout.Dimensions {
+							stats := panel.Operations[row]
+							fg := color.NRGBA{R: 0xCC, G: 0xCC, B: 0xCC, A: 0xFF}
+							if stats.Errors > 0 {
+								fg = color.NRGBA{R: 0xFF, G: 0x90, B: 0x90, A: 0xFF}
+							}
+							return panel.rows[row].Layout(gtx, func(gtx layout.Context) layout.Dimensions {
+								dims := panel.layoutRow(gtx, func(gtx layout.Context, i int) layout.Dimensions {
+									return panel.label(gtx, th, i, statsColumns[i].Value(stats), fg)
+								})
+								if stats == panel.Selected {
+									paint.FillShape(gtx.Ops, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0x20}, clip.Rect{Max: dims.Size}.Op())
+								}
+								return dims
+							})
+						})
+					}),
+				)
+			})
+		}),
+	)
+}
+
+// layoutRow lays out the cells of a row, the first column takes the remaining space.
+func (panel *StatsPanel) layoutRow(gtx layout.Context, cell func(gtx layout.Context, i int) layout.Dimensions) layout.Dimensions {
+	children := make([]layout.FlexChild, len(statsColumns))
+	for i := range statsColumns {
+		if i == 0 {
+			children[i] = layout.Flexed(1, func(gtx layout.Context) layout.Dimensions {
+				gtx.Constraints.Min.X = gtx.Constraints.Max.X
+				return cell(gtx, i)
+			})
+			continue
+		}
+		children[i] = layout.Rigid(func(gtx layout.Context) layout.Dimensions {
+			width := gtx.Dp(unit.Dp(64))
+			gtx.Constraints.Min.X = width
+			gtx.Constraints.Max.X = width
+			return cell(gtx, i)
+		})
+	}
+	return layout.Flex{Axis: layout.Horizontal}.Layout(gtx, children...)
+}
+
+func (panel *StatsPanel) label(gtx layout.Context, th *material.Theme, column int, txt string, fg color.NRGBA) layout.Dimensions {
+	lbl := material.Caption(th, txt)
+	lbl.Color = fg
+	lbl.MaxLines = 1
+	if column > 0 {
+		lbl.Alignment = text.End
+	}
+	return lbl.Layout(gtx)
+}
+
+func (panel *StatsPanel) sort() {
+	less := statsColumns[panel.SortBy].Less
+	sort.SliceStable(panel.Operations, func(i, k int) bool {
+		if panel.Descending {
+			return less(panel.Operations[k], panel.Operations[i])
+		}
+		return less(panel.Operations[i], panel.Operations[k])
+	})
+}
@@ -7,6 +7,7 @@ import (
 
 	"gioui.org/f32"
 	"gioui.org/io/event"
+	"gioui.org/io/key"
 	"gioui.org/io/pointer"
 	"gioui.org/layout"
 	"gioui.org/op"
@@ -24,11 +25,25 @@ type Viewport struct {
 	ScrollX        int
 	scrollTag      bool
 	clickTag       bool
+	focusTag       bool
 	ZoomOffset     trace.Time
 	SpansViewportH int
 
 	Clicked  bool
 	ClickPos f32.Point
+
+	// focusRequested is set after focusing the timeline on the first frame.
+	focusRequested bool
+
+	// Reveal is the span to scroll into view on the next frame.
+	Reveal *trace.Span
+	// RevealCenter centers the revealed span row, instead of
+	// scrolling just enough to make it visible.
+	RevealCenter bool
+
+	Pan     Pan
+	Minimap Minimap
+	Brush   Brush
 }
 
 // RenderOrder groups visible spans into non-overlapping rows for rendering.
@@ -81,6 +96,15 @@ type TimelineView struct {
 
 	ZoomStart  trace.Time
 	ZoomFinish trace.Time
+
+	// Critical is the highlighted critical path, nil when disabled.
+	Critical CriticalPath
+	// SelfTime is used for coloring spans, nil when disabled.
+	SelfTime map[*trace.Span]trace.Time
+	// Highlight dims the spans of other operations, when not nil.
+	Highlight *trace.OperationStats
+	// Matches are the spans matching the search query.
+	Matches map[*trace.Span]bool
 }
 
 func (view *TimelineView) Minimap(gtx layout.Context) layout.Dimensions {
@@ -94,6 +118,8 @@ func (view *TimelineView) Minimap(gtx layout.Context) layout.Dimensions {
 	}
 	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
 
+	view.handleMinimap(gtx, size)
+
 	rowHeight := max(int(float32(size.Y)/float32(len(view.Visible.Rows))), 1)
 
 	topY := 0
@@ -199,6 +225,8 @@ func (view *TimelineView) Ruler(gtx layout.Context) layout.Dimensions {
 
 	defer clip.Rect{Max: size}.Push(gtx.Ops).Pop()
 	paint.FillShape(gtx.Ops, color.NRGBA{0x30, 0x30, 0x38, 0xFF}, clip.Rect{Max: size}.Op())
+	view.handleBrush(gtx, &view.UI.Viewport.Brush.rulerTag, size.X)
+	defer view.drawBrush(gtx, size, false)
 
 	tickNs, firstTick, pxPerNs := view.tickLayout(size.X)
 	if tickNs <= 0 {
@@ -270,19 +298,26 @@ func (view *TimelineView) Spans(gtx layout.Context) layout.Dimensions {
 	rowAdvance := rowHeight
 	totalHeight := totalRows * rowAdvance
 
-	// Handle scroll events for both axes.
+	// Handle scroll events for both axes, Ctrl+scroll zooms.
+	// Gio does not deliver pinch gestures, so zooming is limited to Ctrl+scroll.
 	event.Op(gtx.Ops, &view.UI.Viewport.scrollTag)
+	scrollRangeY := max(totalHeight, size.Y)
 	for {
 		ev, ok := gtx.Source.Event(pointer.Filter{
 			Target:  &view.UI.Viewport.scrollTag,
 			Kinds:   pointer.Scroll,
 			ScrollX: pointer.ScrollRange{Min: -size.X, Max: size.X},
-			ScrollY: pointer.ScrollRange{Min: -totalHeight, Max: totalHeight},
+			ScrollY: pointer.ScrollRange{Min: -scrollRangeY, Max: scrollRangeY},
 		})
 		if !ok {
 			break
 		}
 		if e, ok := ev.(pointer.Event); ok && e.Kind == pointer.Scroll {
+			if e.Modifiers.Contain(key.ModCtrl) || e.Modifiers.Contain(key.ModShortcut) {
+				view.zoomAt(float64(e.Position.X)/float64(size.X), e.Scroll.Y)
+				view.zoomChanged(gtx)
+				continue
+			}
 			view.UI.Viewport.ScrollY += int(e.Scroll.Y)
 			view.UI.Viewport.ScrollX += int(e.Scroll.X)
 		}
@@ -291,18 +326,37 @@ func (view *TimelineView) Spans(gtx layout.Context) layout.Dimensions {
 	// Register for click events (processed in UI.Layout).
 	event.Op(gtx.Ops, &view.UI.Viewport.clickTag)
 
-	// Clamp vertical scroll.
-	maxScrollY := totalHeight - size.Y
-	if maxScrollY < 0 {
-		maxScrollY = 0
+	// Handle dragging with the middle button or space+drag.
+	event.Op(gtx.Ops, &view.UI.Viewport.Pan.tag)
+	panDelta := view.UI.Viewport.Pan.update(gtx)
+	switch {
+	case view.UI.Viewport.Pan.Active():
+		pointer.CursorGrabbing.Add(gtx.Ops)
+	case view.UI.Viewport.Pan.SpaceHeld:
+		pointer.CursorGrab.Add(gtx.Ops)
+	}
+
+	// Handle Shift+drag to select a range to zoom into.
+	view.handleBrush(gtx, &view.UI.Viewport.Brush.spansTag, size.X)
+
+	if span := view.UI.Viewport.Reveal; span != nil {
+		view.UI.Viewport.Reveal = nil
+		view.reveal(span, size, rowAdvance)
 	}
-	view.UI.Viewport.ScrollY = max(0, min(view.UI.Viewport.ScrollY, maxScrollY))
 
 	// Apply horizontal scroll as zoom offset.
-	zoomDuration := view.ZoomFinish - view.ZoomStart
+	zoomDuration := trace.NewTime(view.UI.ZoomLevel.Value)
 	pxToDuration := float64(zoomDuration) / float64(size.X)
 	view.UI.Viewport.ZoomOffset += trace.Time(float64(view.UI.Viewport.ScrollX) * pxToDuration)
 	view.UI.Viewport.ScrollX = 0
+	view.UI.Viewport.Pan.apply(&view.UI.Viewport, panDelta, pxToDuration)
+
+	// Clamp vertical scroll.
+	maxScrollY := totalHeight - size.Y
+	if maxScrollY < 0 {
+		maxScrollY = 0
+	}
+	view.UI.Viewport.ScrollY = max(0, min(view.UI.Viewport.ScrollY, maxScrollY))
 
 	// Clamp zoom offset.
 	maxOffset := view.UI.Timeline.Finish - view.UI.Timeline.Start - trace.NewTime(view.UI.ZoomLevel.Value)
@@ -355,10 +409,12 @@ func (view *TimelineView) Spans(gtx layout.Context) layout.Dimensions {
 			if span.Finish < view.ZoomStart || view.ZoomFinish < span.Start {
 				continue
 			}
-			view.drawSpanCaption(gtx, span, clip.Rect{
+			bounds := clip.Rect{
 				Min: image.Point{X: x0, Y: topY},
 				Max: image.Point{X: x1, Y: topY + rowHeight},
-			})
+			}
+			view.drawSpanCaption(gtx, span, bounds)
+			view.drawCriticalPath(gtx, span, bounds, durationToPx)
 		}
 		topY += rowAdvance
 	}
@@ -410,30 +466,110 @@ func (view *TimelineView) Spans(gtx layout.Context) layout.Dimensions {
 		)
 	}()
 
+	view.drawBrush(gtx, size, true)
+
 	return layout.Dimensions{
 		Size: size,
 	}
 }
 
+// zoomSpeed is the exponent of the zoom factor per scrolled pixel.
+const zoomSpeed = 1.0 / 200
+
+// zoomAt zooms logarithmically by the scroll amount, keeping the time at
+// the fraction frac of the view width in place. Scrolling down zooms out.
+func (view *TimelineView) zoomAt(frac float64, scroll float32) {
+	zoom := view.ZoomFinish - view.ZoomStart
+	anchor := view.ZoomStart + trace.Time(frac*float64(zoom))
+
+	next := trace.Time(math.Round(float64(zoom) * math.Exp(float64(scroll)*zoomSpeed)))
+	if next == zoom {
+		// Ensure progress at nanosecond zoom levels.
+		switch {
+		case scroll > 0:
+			next++
+		case scroll < 0:
+			next--
+		}
+	}
+	view.UI.ZoomAround(anchor, frac, next)
+}
+
+// zoomChanged updates the zoom window after the zoom level or offset
+// changed outside of the zoom level editor and redraws, so the editor
+// shows the new value.
+func (view *TimelineView) zoomChanged(gtx layout.Context) {
+	view.ZoomStart = view.Timeline.Start + view.UI.Viewport.ZoomOffset
+	view.ZoomFinish = view.ZoomStart + trace.NewTime(view.UI.ZoomLevel.Value)
+	gtx.Execute(op.InvalidateCmd{})
+}
+
+// reveal scrolls vertically to show the row of span and moves the
+// zoom window when the span is outside of it.
+func (view *TimelineView) reveal(span *trace.Span, size image.Point, rowAdvance int) {
+	viewport := &view.UI.Viewport
+	for i, row := range view.Visible.Rows {
+		for _, s := range view.Visible.Spans[row.Low:row.High] {
+			if s != span {
+				continue
+			}
+			top := i * rowAdvance
+			switch {
+			case viewport.RevealCenter:
+				viewport.ScrollY = top + rowAdvance/2 - size.Y/2
+			case top < viewport.ScrollY:
+				viewport.ScrollY = top
+			case top+rowAdvance > viewport.ScrollY+size.Y:
+				viewport.ScrollY = top + rowAdvance - size.Y
+			}
+		}
+	}
+
+	if span.Start < view.ZoomStart || view.ZoomFinish < span.Finish {
+		zoom := view.ZoomFinish - view.ZoomStart
+		center := span.Start + span.Duration()/2
+		view.UI.Viewport.ZoomOffset = center - zoom/2 - view.Timeline.Start
+		if span.Duration() > zoom {
+			view.UI.Viewport.ZoomOffset = span.Start - view.Timeline.Start
+		}
+	}
+}
+
+// spanColor returns the fill color of span.
+func (view *TimelineView) spanColor(span *trace.Span) color.NRGBA {
+	var c color.NRGBA
+	if self, ok := view.SelfTime[span]; ok {
+		c = selfTimeColor(selfTimeShare(span, self))
+	} else {
+		c = spanColor(span)
+	}
+	if view.Highlight != nil && trace.OperationOf(span) != view.Highlight.Operation {
+		c = dim(c)
+	}
+	return c
+}
+
 func (view *TimelineView) drawSpan(gtx layout.Context, span *trace.Span, bounds clip.Rect) {
-	paint.FillShape(gtx.Ops, spanColor(int64(span.SpanID), int64(span.TraceID)), bounds.Op())
+	c := view.spanColor(span)
+	if view.Matches[span] {
+		c = matchColor
+	}
+	paint.FillShape(gtx.Ops, c, bounds.Op())
 }
 
 func (view *TimelineView) drawSpanCaption(gtx layout.Context, span *trace.Span, bounds clip.Rect) {
-	bg := spanColor(int64(span.SpanID), int64(span.TraceID))
+	bg := view.spanColor(span)
 	if view.UI.Selected == span {
 		bg = brighten(bg)
 	}
 	paint.FillShape(gtx.Ops, bg, bounds.Op())
 
-	// Draw selection border.
-	if view.UI.Selected == span {
-		border := color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xCC}
-		b := bounds
-		paint.FillShape(gtx.Ops, border, clip.Rect{Min: b.Min, Max: image.Point{X: b.Max.X, Y: b.Min.Y + 1}}.Op())
-		paint.FillShape(gtx.Ops, border, clip.Rect{Min: image.Point{X: b.Min.X, Y: b.Max.Y - 1}, Max: b.Max}.Op())
-		paint.FillShape(gtx.Ops, border, clip.Rect{Min: b.Min, Max: image.Point{X: b.Min.X + 1, Y: b.Max.Y}}.Op())
-		paint.FillShape(gtx.Ops, border, clip.Rect{Min: image.Point{X: b.Max.X - 1, Y: b.Min.Y}, Max: b.Max}.Op())
+	// Draw selection or search match border.
+	switch {
+	case view.UI.Selected == span:
+		drawBorder(gtx, bounds, color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xCC})
+	case view.Matches[span]:
+		drawBorder(gtx, bounds, matchColor)
 	}
 
 	defer bounds.Op().Push(gtx.Ops).Pop()
@@ -447,3 +583,10 @@ func (view *TimelineView) drawSpanCaption(gtx layout.Context, span *trace.Span,
 	label.Color = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xDD}
 	label.Layout(gtx)
 }
+
+func drawBorder(gtx layout.Context, b clip.Rect, border color.NRGBA) {
+	paint.FillShape(gtx.Ops, border, clip.Rect{Min: b.Min, Max: image.Point{X: b.Max.X, Y: b.Min.Y + 1}}.Op())
+	paint.FillShape(gtx.Ops, border, clip.Rect{Min: image.Point{X: b.Min.X, Y: b.Max.Y - 1}, Max: b.Max}.Op())
+	paint.FillShape(gtx.Ops, border, clip.Rect{Min: b.Min, Max: image.Point{X: b.Min.X + 1, Y: b.Max.Y}}.Op())
+	paint.FillShape(gtx.Ops, border, clip.Rect{Min: image.Point{X: b.Max.X - 1, Y: b.Min.Y}, Max: b.Max}.Op())
+}
@@ -0,0 +1,129 @@
+package trace
+
+import "sort"
+
+// Segment is a part of a span.
+type Segment struct {
+	Span *Span
+	TimeRange
+}
+
+// CriticalPath returns the segments that determined the completion of the
+// trace, ordered by time.
+//
+// Starting from the end of a root span, the path walks backwards and
+// repeatedly descends into the child that finished last, before the current
+// point in time. The time not covered by any child belongs to the span itself.
+// Spans that follow from a span are treated the same way as children, and
+// they extend the span until they complete, even when they start after the
+// span has finished. Spans that follow from another span in the same trace
+// are reached through it and are not treated as roots.
+func (tr *Trace) CriticalPath() []Segment {
+	var path []Segment
+	for _, span := range tr.Spans {
+		if len(span.Parents) == 0 && !span.followsFromTrace() {
+			path = append(path, CriticalPath(span)...)
+		}
+	}
+	sort.SliceStable(path, func(i, k int) bool {
+		return path[i].Start < path[k].Start
+	})
+	return path
+}
+
+// followsFromTrace reports whether span follows from a span in the same trace.
+func (span *Span) followsFromTrace() bool {
+	for _, from := range span.FollowsFrom {
+		if from.TraceID == span.TraceID {
+			return true
+		}
+	}
+	return false
+}
+
+// CriticalPath returns the segments of root and its descendants
+// that determined the completion of root, ordered by time.
+func CriticalPath(root *Span) []Segment {
+	walk := criticalWalk{
+		visiting: make(map[*Span]bool),
+		finish:   make(map[*Span]Time),
+	}
+	bounds := TimeRange{Start: root.Start, Finish: walk.finishOf(root)}
+	path := walk.path(nil, root, bounds)
+	for i, k := 0, len(path)-1; i < k; i, k = i+1, k-1 {
+		path[i], path[k] = path[k], path[i]
+	}
+	return path
+}
+
+// criticalWalk is the state of the critical path computation.
+type criticalWalk struct {
+	visiting map[*Span]bool
+	finish   map[*Span]Time
+}
+
+// finishOf returns the finish of span, extended by the completion
+// of the spans that follow from it.
+func (walk *criticalWalk) finishOf(span *Span) Time {
+	if finish, ok := walk.finish[span]; ok {
+		return finish
+	}
+	// Guard against cycles while following.
+	walk.finish[span] = span.Finish
+
+	finish := span.Finish
+	for _, next := range span.FollowedBy {
+		finish = max(finish, walk.finishOf(next))
+	}
+	walk.finish[span] = finish
+	return finish
+}
+
+// path appends the segments of span within bounds to path in reverse order.
+func (walk *criticalWalk) path(path []Segment, span *Span, bounds TimeRange) []Segment {
+	if walk.visiting[span] {
+		return path
+	}
+	walk.visiting[span] = true
+	defer delete(walk.visiting, span)
+
+	start := max(span.Start, bounds.Start)
+	cursor := min(walk.finishOf(span), bounds.Finish)
+
+	blocking := make([]*Span, 0, len(span.Children)+len(span.FollowedBy))
+	blocking = append(blocking, span.Children...)
+	blocking = append(blocking, span.FollowedBy...)
+	sort.SliceStable(blocking, func(i, k int) bool {
+		return walk.finishOf(blocking[i]) > walk.finishOf(blocking[k])
+	})
+
+	for _, child := range blocking {
+		if cursor <= start {
+			break
+		}
+		if walk.visiting[child] {
+			continue
+		}
+		childFinish := min(walk.finishOf(child), cursor)
+		if child.Start >= cursor || childFinish <= start {
+			continue
+		}
+
+		path = appendSelf(path, span, childFinish, cursor)
+		path = walk.path(path, child, TimeRange{Start: start, Finish: childFinish})
+		cursor = max(child.Start, start)
+	}
+
+	return appendSelf(path, span, start, cursor)
+}
+
+// appendSelf appends the part of span between start and finish to path.
+// The time after the span has finished, while waiting for the spans
+// following from it, doesn't belong to any span.
+func appendSelf(path []Segment, span *Span, start, finish Time) []Segment {
+	finish = min(finish, span.Finish)
+	if start >= finish {
+		return path
+	}
+	return append(path, Segment{Span: span, TimeRange: TimeRange{Start: start, Finish: finish}})
+}
@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// follow adds the spans following from span.
+func follow(span *Span, next ...*Span) *Span {
+	for _, n := range next {
+		n.FollowsFrom = append(n.FollowsFrom, span)
+		span.FollowedBy = append(span.FollowedBy, n)
+	}
+	return span
+}
+
+// segmentStrings formats the segments as "caption start-finish".
+func segmentStrings(path []Segment) []string {
+	var out []string
+	for _, segment := range path {
+		out = append(out, fmt.Sprintf("%s %d-%d", segment.Span.Caption, segment.Start, segment.Finish))
+	}
+	return out
+}
+
+func TestCriticalPath(t *testing.T) {
+	tests := []struct {
+		name string
+		root func() *Span
+		exp  []string
+	}{
+		{
+			name: "no children",
+			root: func() *Span { return testSpan("p", 0, 100) },
+			exp:  []string{"p 0-100"},
+		},
+		{
+			name: "overlapping children",
+			root: func() *Span {
+				return link(testSpan("p", 0, 100), testSpan("a", 10, 50), testSpan("b", 30, 80))
+			},
+			exp: []string{"p 0-10", "a 10-30", "b 30-80", "p 80-100"},
+		},
+		{
+			name: "contained child",
+			root: func() *Span {
+				return link(testSpan("p", 0, 100), testSpan("a", 10, 90), testSpan("b", 20, 60))
+			},
+			exp: []string{"p 0-10", "a 10-90", "p 90-100"},
+		},
+		{
+			name: "sequential children",
+			root: func() *Span {
+				return link(testSpan("p", 0, 100), testSpan("a", 10, 40), testSpan("b", 50, 90))
+			},
+			exp: []string{"p 0-10", "a 10-40", "p 40-50", "b 50-90", "p 90-100"},
+		},
+		{
+			name: "nested children",
+			root: func() *Span {
+				return link(testSpan("p", 0, 100),
+					link(testSpan("a", 10, 90), testSpan("b", 20, 40), testSpan("c", 30, 60)))
+			},
+			exp: []string{"p 0-10", "a 10-20", "b 20-30", "c 30-60", "a 60-90", "p 90-100"},
+		},
+		{
+			name: "child outside of parent",
+			root: func() *Span {
+				return link(testSpan("p", 0, 100), testSpan("a", 80, 120))
+			},
+			exp: []string{"p 0-80", "a 80-100"},
+		},
+		{
+			name: "follows from after finish",
+			root: func() *Span {
+				p := link(testSpan("p", 0, 100), testSpan("a", 10, 50))
+				return follow(p, testSpan("f", 120, 150))
+			},
+			exp: []string{"p 0-10", "a 10-50", "p 50-100", "f 120-150"},
+		},
+		{
+			name: "follows from child",
+			root: func() *Span {
+				a := follow(testSpan("a", 10, 30), testSpan("f", 40, 70))
+				return link(testSpan("p", 0, 100), a)
+			},
+			exp: []string{"p 0-10", "a 10-30", "f 40-70", "p 70-100"},
+		},
+		{
+			name: "follows from cycle",
+			root: func() *Span {
+				p, f := testSpan("p", 0, 100), testSpan("f", 50, 150)
+				follow(p, f)
+				follow(f, p)
+				return p
+			},
+			exp: []string{"p 0-50", "f 50-150"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := segmentStrings(CriticalPath(test.root()))
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Fatalf("got %q, expected %q", got, test.exp)
+			}
+		})
+	}
+}
+
+func TestTraceCriticalPath(t *testing.T) {
+	late := link(testSpan("late", 200, 300), testSpan("x", 210, 290))
+	early := follow(testSpan("early", 0, 100), testSpan("f", 150, 180))
+	// Not sorted by time on purpose.
+	tr := &Trace{Spans: []*Span{late, late.Children[0], early.FollowedBy[0], early}}
+
+	got := segmentStrings(tr.CriticalPath())
+	exp := []string{"early 0-100", "f 150-180", "late 200-210", "x 210-290", "late 290-300"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %q, expected %q", got, exp)
+	}
+}
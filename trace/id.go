@@ -0,0 +1,79 @@
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// TraceID is a 128-bit trace identifier.
+//
+// Systems with 64-bit trace IDs only use the Low part.
+type TraceID struct {
+	High uint64
+	Low  uint64
+}
+
+// SpanID is a 64-bit span identifier.
+type SpanID uint64
+
+// ErrEmptyID is returned when parsing an empty ID.
+var ErrEmptyID = errors.New("empty id")
+
+// ParseTraceID parses a hex encoded trace ID of up to 32 digits.
+func ParseTraceID(s string) (TraceID, error) {
+	if s == "" {
+		return TraceID{}, ErrEmptyID
+	}
+	if len(s) > 32 {
+		return TraceID{}, fmt.Errorf("too long id: %d digits", len(s))
+	}
+
+	var id TraceID
+	if len(s) > 16 {
+		high, err := strconv.ParseUint(s[:len(s)-16], 16, 64)
+		if err != nil {
+			return TraceID{}, err
+		}
+		id.High = high
+		s = s[len(s)-16:]
+	}
+	low, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return TraceID{}, err
+	}
+	id.Low = low
+	return id, nil
+}
+
+// ParseSpanID parses a hex encoded span ID of up to 16 digits.
+func ParseSpanID(s string) (SpanID, error) {
+	if s == "" {
+		return 0, ErrEmptyID
+	}
+	id, err := strconv.ParseUint(s, 16, 64)
+	return SpanID(id), err
+}
+
+func (id TraceID) IsZero() bool { return id == TraceID{} }
+func (id SpanID) IsZero() bool  { return id == 0 }
+
+func (id TraceID) Less(b TraceID) bool {
+	if id.High == b.High {
+		return id.Low < b.Low
+	}
+	return id.High < b.High
+}
+
+// String formats the ID as 16 hex digits, or 32 when High is used.
+func (id TraceID) String() string {
+	if id.High == 0 {
+		return fmt.Sprintf("%016x", id.Low)
+	}
+	return fmt.Sprintf("%016x%016x", id.High, id.Low)
+}
+
+// String formats the ID as 16 hex digits.
+func (id SpanID) String() string {
+	return fmt.Sprintf("%016x", uint64(id))
+}
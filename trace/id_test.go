@@ -0,0 +1,101 @@
+package trace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceID(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp TraceID
+		str string
+		err bool
+	}{
+		{in: "1", exp: TraceID{Low: 1}, str: "0000000000000001"},
+		{in: "00000000000000ab", exp: TraceID{Low: 0xab}, str: "00000000000000ab"},
+		{in: "fedcba9876543210", exp: TraceID{Low: 0xfedcba9876543210}, str: "fedcba9876543210"},
+		{
+			in:  "0123456789abcdeffedcba9876543210",
+			exp: TraceID{High: 0x0123456789abcdef, Low: 0xfedcba9876543210},
+			str: "0123456789abcdeffedcba9876543210",
+		},
+		{in: "100000000000000002", exp: TraceID{High: 0x10, Low: 2}, str: "00000000000000100000000000000002"},
+		// Leading zeros in the high part are the same as a 64-bit ID.
+		{in: "00000000000000000000000000000abc", exp: TraceID{Low: 0xabc}, str: "0000000000000abc"},
+		{in: "ABCDEF", exp: TraceID{Low: 0xabcdef}, str: "0000000000abcdef"},
+		{in: "", err: true},
+		{in: strings.Repeat("1", 33), err: true},
+		{in: "xyz", err: true},
+		{in: "0123456789abcdefg0123456789abcde", err: true},
+		{in: "-1", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			id, err := ParseTraceID(test.in)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", id)
+				}
+				if test.in == "" && !errors.Is(err, ErrEmptyID) {
+					t.Fatalf("got %v, expected ErrEmptyID", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != test.exp {
+				t.Fatalf("got %#v, expected %#v", id, test.exp)
+			}
+			if got := id.String(); got != test.str {
+				t.Fatalf("String: got %q, expected %q", got, test.str)
+			}
+			if back, err := ParseTraceID(id.String()); err != nil || back != id {
+				t.Fatalf("round trip: got %#v, %v", back, err)
+			}
+		})
+	}
+}
+
+func TestParseSpanID(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp SpanID
+		err bool
+	}{
+		{in: "1", exp: 1},
+		{in: "00000000000000ab", exp: 0xab},
+		{in: "8000000000000000", exp: 1 << 63},
+		{in: "ffffffffffffffff", exp: 0xffffffffffffffff},
+		{in: "", err: true},
+		{in: "10000000000000000", err: true},
+		{in: "xyz", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			id, err := ParseSpanID(test.in)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", id)
+				}
+				if test.in == "" && !errors.Is(err, ErrEmptyID) {
+					t.Fatalf("got %v, expected ErrEmptyID", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != test.exp {
+				t.Fatalf("got %v, expected %v", id, test.exp)
+			}
+			if back, err := ParseSpanID(id.String()); err != nil || back != id {
+				t.Fatalf("round trip: got %v, %v", back, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package trace
+
+import "strings"
+
+// SpanKind describes the relationship of the span to the other spans.
+type SpanKind uint8
+
+const (
+	SpanKindUnspecified SpanKind = iota
+	SpanKindInternal
+	SpanKindServer
+	SpanKindClient
+	SpanKindProducer
+	SpanKindConsumer
+)
+
+var spanKindNames = [...]string{
+	SpanKindUnspecified: "",
+	SpanKindInternal:    "internal",
+	SpanKindServer:      "server",
+	SpanKindClient:      "client",
+	SpanKindProducer:    "producer",
+	SpanKindConsumer:    "consumer",
+}
+
+// ParseSpanKind parses the kind name, ignoring the case.
+// Unknown names are parsed as SpanKindUnspecified.
+func ParseSpanKind(name string) SpanKind {
+	for kind, kindName := range spanKindNames {
+		if kindName != "" && strings.EqualFold(name, kindName) {
+			return SpanKind(kind)
+		}
+	}
+	return SpanKindUnspecified
+}
+
+func (kind SpanKind) String() string {
+	if int(kind) < len(spanKindNames) {
+		return spanKindNames[kind]
+	}
+	return ""
+}
+
+// StatusCode is the outcome of a span.
+type StatusCode uint8
+
+const (
+	StatusUnset StatusCode = iota
+	StatusOK
+	StatusError
+)
+
+func (code StatusCode) String() string {
+	switch code {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return ""
+	}
+}
+
+// Status is the outcome of a span with an optional message.
+type Status struct {
+	Code    StatusCode
+	Message string
+}
+
+func (status Status) IsError() bool { return status.Code == StatusError }
+
+func (status Status) String() string {
+	if status.Message == "" {
+		return status.Code.String()
+	}
+	return status.Code.String() + ": " + status.Message
+}
@@ -0,0 +1,186 @@
+package trace
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// Merge combines multiple timelines into one.
+//
+// Spans with the same ID are deduplicated, keeping the first one and
+// adding the tags and logs of the duplicates that it doesn't have.
+// Placeholder spans, which are referenced but not part of any trace,
+// are replaced by the actual span from another timeline.
+//
+// The input timelines must not be used after merging.
+func Merge(timelines ...*Timeline) *Timeline {
+	if len(timelines) == 1 {
+		return timelines[0]
+	}
+
+	merged := &Timeline{
+		SpanByID:  make(map[TraceSpanID]*Span),
+		TimeRange: InvalidRange,
+	}
+
+	// canonical maps every span to the span that replaces it.
+	canonical := make(map[*Span]*Span)
+	// aliases lists all the spans that are replaced by a canonical span.
+	aliases := make(map[*Span][]*Span)
+	// canonicals is the list of canonical spans in insertion order.
+	var canonicals []*Span
+
+	alias := func(span, canon *Span) {
+		if _, ok := aliases[canon]; !ok {
+			canonicals = append(canonicals, canon)
+		}
+		canonical[span] = canon
+		aliases[canon] = append(aliases[canon], span)
+	}
+
+	// Spans that are not indexed by SpanByID share the ID with
+	// another span, hence they are deduplicated by their time as well.
+	type unindexedKey struct {
+		TraceSpanID
+		TimeRange
+	}
+	unindexed := make(map[unindexedKey]*Span)
+
+	var spans []*Span
+	for _, timeline := range timelines {
+		for _, tr := range timeline.Traces {
+			for _, span := range tr.Spans {
+				if timeline.SpanByID[span.TraceSpanID] != span {
+					key := unindexedKey{span.TraceSpanID, span.TimeRange}
+					if existing, ok := unindexed[key]; ok {
+						alias(span, existing)
+						continue
+					}
+					unindexed[key] = span
+					alias(span, span)
+					spans = append(spans, span)
+					continue
+				}
+
+				if existing, ok := merged.SpanByID[span.TraceSpanID]; ok {
+					existing.mergeContent(span)
+					alias(span, existing)
+					continue
+				}
+				merged.SpanByID[span.TraceSpanID] = span
+				alias(span, span)
+				spans = append(spans, span)
+			}
+		}
+	}
+
+	// Include placeholders that were not resolved by any timeline.
+	for _, timeline := range timelines {
+		var placeholders []*Span
+		for _, span := range timeline.SpanByID {
+			if _, ok := canonical[span]; !ok {
+				placeholders = append(placeholders, span)
+			}
+		}
+		sort.Slice(placeholders, func(i, k int) bool {
+			return placeholders[i].TraceSpanID.Less(placeholders[k].TraceSpanID)
+		})
+
+		for _, span := range placeholders {
+			if existing, ok := merged.SpanByID[span.TraceSpanID]; ok {
+				alias(span, existing)
+				continue
+			}
+			merged.SpanByID[span.TraceSpanID] = span
+			alias(span, span)
+		}
+	}
+
+	// Relink all the spans.
+	relink := func(canon *Span, links []*Span, list []*Span) []*Span {
+	next:
+		for _, link := range links {
+			link = canonical[link]
+			if link == nil || link == canon {
+				continue
+			}
+			for _, existing := range list {
+				if existing == link {
+					continue next
+				}
+			}
+			list = append(list, link)
+		}
+		return list
+	}
+	for _, canon := range canonicals {
+		var parents, children, followsFrom, followedBy []*Span
+		for _, span := range aliases[canon] {
+			parents = relink(canon, span.Parents, parents)
+			children = relink(canon, span.Children, children)
+			followsFrom = relink(canon, span.FollowsFrom, followsFrom)
+			followedBy = relink(canon, span.FollowedBy, followedBy)
+		}
+		canon.Parents = parents
+		canon.Children = children
+		canon.FollowsFrom = followsFrom
+		canon.FollowedBy = followedBy
+	}
+
+	// Processes from different timelines may describe the same process.
+	var processes ProcessSet
+	sameProcess := make(map[*Process]*Process)
+	for _, timeline := range timelines {
+		for _, proc := range timeline.Processes {
+			sameProcess[proc] = processes.Add(proc.Service, proc.Tags)
+		}
+	}
+	merged.Processes = processes.List
+
+	traceByID := make(map[TraceID]*Trace)
+	for _, span := range spans {
+		if proc, ok := sameProcess[span.Process]; ok {
+			span.Process = proc
+		}
+
+		tr, ok := traceByID[span.TraceID]
+		if !ok {
+			tr = &Trace{
+				TraceID:   span.TraceID,
+				TimeRange: InvalidRange,
+			}
+			merged.Traces = append(merged.Traces, tr)
+			traceByID[span.TraceID] = tr
+		}
+		tr.Spans = append(tr.Spans, span)
+		tr.TimeRange = tr.TimeRange.Expand(span.TimeRange)
+		merged.TimeRange = merged.TimeRange.Expand(span.TimeRange)
+	}
+
+	merged.Sort()
+	return merged
+}
+
+// mergeContent adds the tags and the logs of other that span doesn't have.
+func (span *Span) mergeContent(other *Span) {
+	for _, tag := range other.Tags {
+		if !slices.ContainsFunc(span.Tags, func(t Tag) bool {
+			return t.Key == tag.Key && t.Value.Equal(tag.Value)
+		}) {
+			span.Tags = append(span.Tags, tag)
+		}
+	}
+	for _, log := range other.Logs {
+		if !slices.ContainsFunc(span.Logs, func(l Log) bool {
+			return l.Timestamp == log.Timestamp && slices.EqualFunc(l.Fields, log.Fields, func(a, b Tag) bool {
+				return a.Key == b.Key && a.Value.Equal(b.Value)
+			})
+		}) {
+			span.Logs = append(span.Logs, log)
+		}
+	}
+	slices.SortStableFunc(span.Logs, func(a, b Log) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+}
@@ -0,0 +1,157 @@
+package trace
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// spanID returns the ID of span in trace 1.
+func spanID(id SpanID) TraceSpanID {
+	return TraceSpanID{TraceID: TraceID{Low: 1}, SpanID: id}
+}
+
+// idSpan creates a span with the ID in trace 1.
+func idSpan(caption string, id SpanID, start, finish Time) *Span {
+	span := testSpan(caption, start, finish)
+	span.TraceSpanID = spanID(id)
+	return span
+}
+
+// fileTimeline creates a timeline of the spans, like an importer would.
+// The spans are indexed by ID, unless they are in unindexed.
+// Placeholders are indexed, but not part of any trace.
+func fileTimeline(spans, unindexed, placeholders []*Span) *Timeline {
+	timeline := testTimeline(append(slices.Clone(spans), unindexed...)...)
+	timeline.Traces[0].TraceID = TraceID{Low: 1}
+	timeline.SpanByID = make(map[TraceSpanID]*Span)
+	for _, span := range append(slices.Clone(spans), placeholders...) {
+		timeline.SpanByID[span.TraceSpanID] = span
+	}
+	return timeline
+}
+
+func captions(spans []*Span) []string {
+	var out []string
+	for _, span := range spans {
+		out = append(out, span.Caption)
+	}
+	return out
+}
+
+func TestMergeParentInOtherFile(t *testing.T) {
+	placeholder := idSpan("", 1, 0, 0)
+	child := idSpan("child", 2, 10, 20)
+	link(placeholder, child)
+	first := fileTimeline([]*Span{child}, nil, []*Span{placeholder})
+
+	parent := idSpan("parent", 1, 0, 100)
+	second := fileTimeline([]*Span{parent}, nil, nil)
+
+	merged := Merge(first, second)
+
+	if got := merged.SpanByID[spanID(1)]; got != parent {
+		t.Fatalf("parent not resolved, got %q", got.Caption)
+	}
+	if !reflect.DeepEqual(child.Parents, []*Span{parent}) {
+		t.Errorf("child parents: got %q", captions(child.Parents))
+	}
+	if !reflect.DeepEqual(parent.Children, []*Span{child}) {
+		t.Errorf("parent children: got %q", captions(parent.Children))
+	}
+	if len(merged.Traces) != 1 {
+		t.Fatalf("got %d traces", len(merged.Traces))
+	}
+	if got, exp := captions(merged.Traces[0].Order), []string{"parent", "child"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("order: got %q, expected %q", got, exp)
+	}
+}
+
+func TestMergeUnresolvedPlaceholder(t *testing.T) {
+	placeholder := idSpan("", 1, 0, 0)
+	child := idSpan("child", 2, 10, 20)
+	link(placeholder, child)
+	first := fileTimeline([]*Span{child}, nil, []*Span{placeholder})
+	second := fileTimeline([]*Span{idSpan("other", 3, 0, 5)}, nil, nil)
+
+	merged := Merge(first, second)
+	if got := merged.SpanByID[spanID(1)]; got != placeholder {
+		t.Fatalf("placeholder not kept")
+	}
+	if !reflect.DeepEqual(child.Parents, []*Span{placeholder}) {
+		t.Errorf("child parents: got %q", captions(child.Parents))
+	}
+	if got := len(merged.Traces[0].Spans); got != 2 {
+		t.Errorf("got %d spans, expected 2", got)
+	}
+}
+
+func TestMergeDuplicateSpan(t *testing.T) {
+	parentA := idSpan("parent", 1, 0, 100)
+	spanA := idSpan("span", 2, 10, 20)
+	spanA.Tags = []Tag{{Key: "a", Value: StringValue("x")}, {Key: "n", Value: IntValue(1)}}
+	spanA.Logs = []Log{{Timestamp: 15}}
+	link(parentA, spanA)
+
+	parentB := idSpan("parent", 1, 0, 100)
+	spanB := idSpan("span", 2, 10, 20)
+	spanB.Tags = []Tag{{Key: "n", Value: FloatValue(1)}, {Key: "b", Value: StringValue("y")}}
+	spanB.Logs = []Log{{Timestamp: 12}, {Timestamp: 15}}
+	link(parentB, spanB)
+
+	merged := Merge(
+		fileTimeline([]*Span{parentA, spanA}, nil, nil),
+		fileTimeline([]*Span{parentB, spanB}, nil, nil),
+	)
+
+	if len(merged.Traces) != 1 {
+		t.Fatalf("got %d traces", len(merged.Traces))
+	}
+	if got, exp := captions(merged.Traces[0].Spans), []string{"parent", "span"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("spans: got %q, expected %q", got, exp)
+	}
+	if merged.SpanByID[spanID(2)] != spanA {
+		t.Fatalf("expected the first span to be kept")
+	}
+	expTags := []Tag{
+		{Key: "a", Value: StringValue("x")},
+		{Key: "n", Value: IntValue(1)},
+		{Key: "b", Value: StringValue("y")},
+	}
+	if !reflect.DeepEqual(spanA.Tags, expTags) {
+		t.Errorf("tags: got %v, expected %v", spanA.Tags, expTags)
+	}
+	if exp := []Log{{Timestamp: 12}, {Timestamp: 15}}; !reflect.DeepEqual(spanA.Logs, exp) {
+		t.Errorf("logs: got %v, expected %v", spanA.Logs, exp)
+	}
+	if !reflect.DeepEqual(parentA.Children, []*Span{spanA}) {
+		t.Errorf("parent children: got %q", captions(parentA.Children))
+	}
+}
+
+func TestMergeSharedSpan(t *testing.T) {
+	// Zipkin client and server halves of a call share the span ID,
+	// only one of them is indexed.
+	shared := func() *Timeline {
+		client := idSpan("client", 1, 0, 100)
+		server := idSpan("server", 1, 10, 90)
+		link(client, server)
+		return fileTimeline([]*Span{client}, []*Span{server}, nil)
+	}
+	first, second := shared(), shared()
+	other := fileTimeline([]*Span{idSpan("other", 2, 200, 300)}, nil, nil)
+
+	merged := Merge(first, second, other)
+
+	if len(merged.Traces) != 1 {
+		t.Fatalf("got %d traces", len(merged.Traces))
+	}
+	got := captions(merged.Traces[0].Order)
+	if exp := []string{"client", "server", "other"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("order: got %q, expected %q", got, exp)
+	}
+	client := merged.SpanByID[spanID(1)]
+	if client.Caption != "client" || len(client.Children) != 1 || client.Children[0].Caption != "server" {
+		t.Errorf("client children: got %q", captions(client.Children))
+	}
+}
@@ -0,0 +1,49 @@
+package trace
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Process describes the process or resource that reported a span.
+// It is shared by all the spans of the process.
+type Process struct {
+	Service string
+	Tags    []Tag
+}
+
+// ProcessSet deduplicates processes with the same service and tags.
+type ProcessSet struct {
+	List []*Process
+
+	byKey map[string]*Process
+}
+
+// Add returns the process with the service and tags,
+// creating it when it does not exist yet.
+func (set *ProcessSet) Add(service string, tags []Tag) *Process {
+	key := processKey(service, tags)
+	if proc, ok := set.byKey[key]; ok {
+		return proc
+	}
+	if set.byKey == nil {
+		set.byKey = make(map[string]*Process)
+	}
+
+	proc := &Process{Service: service, Tags: tags}
+	set.byKey[key] = proc
+	set.List = append(set.List, proc)
+	return proc
+}
+
+func processKey(service string, tags []Tag) string {
+	var b strings.Builder
+	b.WriteString(strconv.Quote(service))
+	for _, tag := range tags {
+		b.WriteByte(' ')
+		b.WriteString(strconv.Quote(tag.Key))
+		b.WriteByte(byte('0' + tag.Value.Kind()))
+		b.WriteString(strconv.Quote(tag.Value.String()))
+	}
+	return b.String()
+}
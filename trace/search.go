@@ -0,0 +1,30 @@
+package trace
+
+// Match reports whether the caption, the service, any of the tags, the
+// process tags or the log fields satisfy match.
+func (span *Span) Match(match func(s string) bool) bool {
+	if match(span.Caption) || match(span.Service) {
+		return true
+	}
+	if matchTags(span.Tags, match) {
+		return true
+	}
+	if span.Process != nil && matchTags(span.Process.Tags, match) {
+		return true
+	}
+	for _, log := range span.Logs {
+		if matchTags(log.Fields, match) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchTags(tags []Tag, match func(s string) bool) bool {
+	for _, tag := range tags {
+		if match(tag.Key) || match(tag.Value.String()) {
+			return true
+		}
+	}
+	return false
+}
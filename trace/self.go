@@ -0,0 +1,36 @@
+package trace
+
+import "sort"
+
+// SelfTime returns the time of the span that is not covered by any child.
+//
+// Overlapping children, such as parallel calls, are counted only once and
+// the parts of children outside of the span are ignored.
+func (span *Span) SelfTime() Time {
+	if len(span.Children) == 0 {
+		return span.Duration()
+	}
+
+	covered := make([]TimeRange, 0, len(span.Children))
+	for _, child := range span.Children {
+		start := max(child.Start, span.Start)
+		finish := min(child.Finish, span.Finish)
+		if start < finish {
+			covered = append(covered, TimeRange{Start: start, Finish: finish})
+		}
+	}
+	sort.Slice(covered, func(i, k int) bool {
+		return covered[i].Start < covered[k].Start
+	})
+
+	self := span.Duration()
+	cursor := span.Start
+	for _, r := range covered {
+		if r.Finish <= cursor {
+			continue
+		}
+		self -= r.Finish - max(r.Start, cursor)
+		cursor = r.Finish
+	}
+	return self
+}
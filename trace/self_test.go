@@ -0,0 +1,45 @@
+package trace
+
+import "testing"
+
+func TestSelfTime(t *testing.T) {
+	tests := []struct {
+		name string
+		span *Span
+		exp  Time
+	}{
+		{
+			name: "no children",
+			span: testSpan("p", 0, 100),
+			exp:  100,
+		},
+		{
+			name: "sequential children",
+			span: link(testSpan("p", 0, 100), testSpan("a", 10, 40), testSpan("b", 50, 90)),
+			exp:  30,
+		},
+		{
+			name: "overlapping children",
+			span: link(testSpan("p", 0, 100), testSpan("a", 10, 50), testSpan("b", 30, 80)),
+			exp:  30,
+		},
+		{
+			name: "contained child",
+			span: link(testSpan("p", 0, 100), testSpan("a", 10, 90), testSpan("b", 20, 60)),
+			exp:  20,
+		},
+		{
+			name: "children outside of span",
+			span: link(testSpan("p", 0, 100), testSpan("a", -50, 20), testSpan("b", 90, 150), testSpan("c", 200, 300)),
+			exp:  70,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.span.SelfTime(); got != test.exp {
+				t.Fatalf("got %d, expected %d", got, test.exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package trace
+
+import (
+	"math"
+	"slices"
+)
+
+// AdjustClockSkew corrects the timestamps of processes, whose clock
+// differs from the clock of the processes calling them.
+//
+// The offset of a process is estimated from the client and server span
+// pairs, where a server span of the process is a child of a client span
+// in another process. Processes without such pairs, e.g. when the spans
+// don't have a kind, use the spans that are children of a span in another
+// process instead. The offset moves the children inside their parents,
+// preferring the smallest change. When the pairs disagree, the median of
+// the offsets that center each child in its parent is used. The offset
+// is applied to all the spans of the process.
+//
+// Processes are adjusted in the call order, starting from the processes
+// that aren't called by other processes. Spans without a process are
+// not adjusted.
+//
+// The applied delta is stored in Span.Skew. It returns the number of
+// adjusted spans.
+func (timeline *Timeline) AdjustClockSkew() int {
+	var processes []*Process
+	calls := make(map[*Process][]clockPair)
+	// contained are the calls from other processes without span kinds.
+	contained := make(map[*Process][]clockPair)
+	for _, tr := range timeline.Traces {
+		for _, span := range tr.Spans {
+			if span.Process == nil {
+				continue
+			}
+			if _, ok := calls[span.Process]; !ok {
+				processes = append(processes, span.Process)
+				calls[span.Process] = nil
+			}
+			for _, parent := range span.Parents {
+				if parent.Process == nil || parent.Process == span.Process {
+					continue
+				}
+				pair := clockPair{client: parent, server: span}
+				if parent.Kind == SpanKindClient && span.Kind == SpanKindServer {
+					calls[span.Process] = append(calls[span.Process], pair)
+				} else {
+					contained[span.Process] = append(contained[span.Process], pair)
+				}
+			}
+		}
+	}
+	for _, process := range processes {
+		if len(calls[process]) == 0 {
+			calls[process] = contained[process]
+		}
+	}
+
+	offsets := make(map[*Process]Time, len(processes))
+	for _, process := range processes {
+		if len(calls[process]) == 0 {
+			offsets[process] = 0
+		}
+	}
+	for len(offsets) < len(processes) {
+		progress := false
+		for _, process := range processes {
+			if _, ok := offsets[process]; ok {
+				continue
+			}
+			var known []clockPair
+			for _, pair := range calls[process] {
+				if offset, ok := offsets[pair.client.Process]; ok {
+					pair.clientOffset = offset
+					known = append(known, pair)
+				}
+			}
+			if len(known) > 0 {
+				offsets[process] = estimateOffset(known)
+				progress = true
+			}
+		}
+		if !progress {
+			// The remaining processes only call each other,
+			// use the first one as the reference.
+			for _, process := range processes {
+				if _, ok := offsets[process]; !ok {
+					offsets[process] = 0
+					break
+				}
+			}
+		}
+	}
+
+	adjusted := 0
+	for _, tr := range timeline.Traces {
+		for _, span := range tr.Spans {
+			if span.Process == nil {
+				continue
+			}
+			if offset := offsets[span.Process]; offset != 0 {
+				span.shift(offset)
+				adjusted++
+			}
+		}
+	}
+
+	if adjusted == 0 {
+		return 0
+	}
+
+	timeline.TimeRange = InvalidRange
+	for _, tr := range timeline.Traces {
+		tr.TimeRange = InvalidRange
+		for _, span := range tr.Spans {
+			tr.TimeRange = tr.TimeRange.Expand(span.TimeRange)
+		}
+		timeline.TimeRange = timeline.TimeRange.Expand(tr.TimeRange)
+	}
+	timeline.Sort()
+
+	return adjusted
+}
+
+// clockPair is a server span called by a client span in another process,
+// or a child span of a parent in another process.
+type clockPair struct {
+	client, server *Span
+	// clientOffset is the offset of the client process.
+	clientOffset Time
+}
+
+// estimateOffset calculates the offset of the server process from pairs.
+func estimateOffset(pairs []clockPair) Time {
+	low, high := Time(math.MinInt64), Time(math.MaxInt64)
+	var centers []Time
+	for _, pair := range pairs {
+		client, server := pair.client, pair.server
+		if server.Duration() > client.Duration() {
+			// The child cannot fit inside the parent.
+			continue
+		}
+		start := client.Start + pair.clientOffset - server.Start
+		finish := client.Finish + pair.clientOffset - server.Finish
+		low, high = max(low, start), min(high, finish)
+		centers = append(centers, start+(finish-start)/2)
+	}
+	if len(centers) == 0 {
+		return 0
+	}
+	if low <= high {
+		return min(max(0, low), high)
+	}
+	slices.Sort(centers)
+	return centers[len(centers)/2]
+}
+
+// shift moves the span and the logs by delta.
+func (span *Span) shift(delta Time) {
+	span.Skew += delta
+	span.Start += delta
+	span.Finish += delta
+	for i := range span.Logs {
+		span.Logs[i].Timestamp += delta
+	}
+}
@@ -0,0 +1,162 @@
+package trace
+
+import "testing"
+
+// testSpan creates a span with the time range.
+func testSpan(caption string, start, finish Time) *Span {
+	return &Span{Caption: caption, TimeRange: TimeRange{Start: start, Finish: finish}}
+}
+
+// link adds the children to parent.
+func link(parent *Span, children ...*Span) *Span {
+	for _, child := range children {
+		child.Parents = append(child.Parents, parent)
+		parent.Children = append(parent.Children, child)
+	}
+	return parent
+}
+
+// testTimeline creates a timeline with a single trace of the spans.
+func testTimeline(spans ...*Span) *Timeline {
+	tr := &Trace{Spans: spans, TimeRange: InvalidRange}
+	for _, span := range spans {
+		tr.TimeRange = tr.TimeRange.Expand(span.TimeRange)
+	}
+	timeline := &Timeline{Traces: []*Trace{tr}, TimeRange: tr.TimeRange}
+	timeline.Sort()
+	return timeline
+}
+
+func TestAdjustClockSkew(t *testing.T) {
+	a, b, c := &Process{Service: "a"}, &Process{Service: "b"}, &Process{Service: "c"}
+
+	type span struct {
+		caption string
+		process *Process
+		kind    SpanKind
+		start   Time
+		finish  Time
+		parent  string
+		// skew is the expected adjustment.
+		skew Time
+	}
+
+	tests := []struct {
+		name  string
+		spans []span
+	}{
+		{
+			name: "server before client",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 500, finish: 560, parent: "client", skew: 500},
+				{caption: "inner", process: b, start: 510, finish: 520, parent: "server", skew: 500},
+			},
+		},
+		{
+			name: "server inside client",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 1010, finish: 1020, parent: "client"},
+			},
+		},
+		{
+			name: "smallest change",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 1090, finish: 1110, parent: "client", skew: -10},
+			},
+		},
+		{
+			name: "offset applies to the whole process",
+			spans: []span{
+				{caption: "client1", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server1", process: b, kind: SpanKindServer, start: 2000, finish: 2050, parent: "client1", skew: -960},
+				{caption: "client2", process: a, kind: SpanKindClient, start: 1200, finish: 1300},
+				{caption: "server2", process: b, kind: SpanKindServer, start: 2190, finish: 2260, parent: "client2", skew: -960},
+				{caption: "other", process: b, start: 5000, finish: 5010, skew: -960},
+			},
+		},
+		{
+			name: "chained processes",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 0, finish: 80, parent: "client", skew: 1000},
+				{caption: "call", process: b, kind: SpanKindClient, start: 10, finish: 70, parent: "server", skew: 1000},
+				{caption: "backend", process: c, kind: SpanKindServer, start: 500, finish: 520, parent: "call", skew: 510},
+			},
+		},
+		{
+			name: "containment without kinds",
+			spans: []span{
+				{caption: "parent", process: a, start: 1000, finish: 1100},
+				{caption: "child", process: b, start: 500, finish: 560, parent: "parent", skew: 500},
+				{caption: "inner", process: b, start: 510, finish: 520, parent: "child", skew: 500},
+			},
+		},
+		{
+			name: "contained without kinds",
+			spans: []span{
+				{caption: "parent", process: a, start: 1000, finish: 1100},
+				{caption: "child", process: b, start: 1010, finish: 1060, parent: "parent"},
+			},
+		},
+		{
+			name: "client and server pairs before containment",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 500, finish: 560, parent: "client", skew: 500},
+				{caption: "parent", process: a, start: 2000, finish: 2100},
+				{caption: "child", process: b, start: 2600, finish: 2700, parent: "parent", skew: 500},
+			},
+		},
+		{
+			name: "no process",
+			spans: []span{
+				{caption: "client", kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", kind: SpanKindServer, start: 500, finish: 560, parent: "client"},
+			},
+		},
+		{
+			name: "server longer than client",
+			spans: []span{
+				{caption: "client", process: a, kind: SpanKindClient, start: 1000, finish: 1100},
+				{caption: "server", process: b, kind: SpanKindServer, start: 500, finish: 700, parent: "client"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			byCaption := map[string]*Span{}
+			var spans []*Span
+			for _, s := range test.spans {
+				span := testSpan(s.caption, s.start, s.finish)
+				span.Process, span.Kind = s.process, s.kind
+				if s.parent != "" {
+					link(byCaption[s.parent], span)
+				}
+				byCaption[s.caption] = span
+				spans = append(spans, span)
+			}
+
+			timeline := testTimeline(spans...)
+			expectAdjusted := 0
+			for _, s := range test.spans {
+				if s.skew != 0 {
+					expectAdjusted++
+				}
+			}
+			if adjusted := timeline.AdjustClockSkew(); adjusted != expectAdjusted {
+				t.Errorf("adjusted %d spans, expected %d", adjusted, expectAdjusted)
+			}
+
+			for _, s := range test.spans {
+				span := byCaption[s.caption]
+				if span.Skew != s.skew || span.Start != s.start+s.skew || span.Finish != s.finish+s.skew {
+					t.Errorf("%s: got skew %d, range %v, expected skew %d", s.caption, span.Skew, span.TimeRange, s.skew)
+				}
+			}
+		})
+	}
+}
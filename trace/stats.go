@@ -0,0 +1,89 @@
+package trace
+
+import "sort"
+
+// Operation identifies spans of the same kind.
+type Operation struct {
+	Service string
+	Caption string
+}
+
+// OperationOf returns the operation of the span.
+func OperationOf(span *Span) Operation {
+	return Operation{Service: span.Service, Caption: span.Caption}
+}
+
+// OperationStats are aggregate statistics of spans of a single operation.
+type OperationStats struct {
+	Operation
+
+	Count  int
+	Errors int
+
+	Total Time
+	Self  Time
+	Mean  Time
+	P50   Time
+	P95   Time
+	P99   Time
+	Max   Time
+
+	Spans []*Span
+}
+
+// Operations aggregates span statistics by operation.
+// The result is sorted by total duration, longest first.
+func (timeline *Timeline) Operations() []*OperationStats {
+	byOperation := make(map[Operation]*OperationStats)
+	var all []*OperationStats
+	for _, tr := range timeline.Traces {
+		for _, span := range tr.Spans {
+			op := OperationOf(span)
+			stats, ok := byOperation[op]
+			if !ok {
+				stats = &OperationStats{Operation: op}
+				byOperation[op] = stats
+				all = append(all, stats)
+			}
+			stats.add(span)
+		}
+	}
+
+	for _, stats := range all {
+		stats.finish()
+	}
+	sort.SliceStable(all, func(i, k int) bool {
+		return all[i].Total > all[k].Total
+	})
+	return all
+}
+
+func (stats *OperationStats) add(span *Span) {
+	stats.Count++
+	if span.Status.IsError() {
+		stats.Errors++
+	}
+	stats.Total += span.Duration()
+	stats.Self += span.SelfTime()
+	stats.Spans = append(stats.Spans, span)
+}
+
+func (stats *OperationStats) finish() {
+	durations := make([]Time, len(stats.Spans))
+	for i, span := range stats.Spans {
+		durations[i] = span.Duration()
+	}
+	sort.Slice(durations, func(i, k int) bool { return durations[i] < durations[k] })
+
+	stats.Mean = stats.Total / Time(stats.Count)
+	stats.P50 = percentile(durations, 50)
+	stats.P95 = percentile(durations, 95)
+	stats.P99 = percentile(durations, 99)
+	stats.Max = durations[len(durations)-1]
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []Time, p int) Time {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
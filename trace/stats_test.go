@@ -0,0 +1,86 @@
+package trace
+
+import "testing"
+
+func TestOperations(t *testing.T) {
+	span := func(service, caption string, start, finish Time, failed bool) *Span {
+		span := testSpan(caption, start, finish)
+		span.Service = service
+		if failed {
+			span.Status.Code = StatusError
+		}
+		return span
+	}
+
+	// api/get twice, with a child covering half of the first one.
+	get1 := span("api", "get", 0, 100, false)
+	get2 := span("api", "get", 200, 300, true)
+	query := span("db", "get", 25, 75, true)
+	link(get1, query)
+	// The same caption in another service is a different operation.
+	put := span("api", "put", 400, 1400, false)
+
+	timeline := testTimeline(get1, query, get2, put)
+	operations := timeline.Operations()
+
+	type result struct {
+		service, caption string
+		count, errors    int
+		total, self      Time
+		mean             Time
+	}
+	exp := []result{
+		{service: "api", caption: "put", count: 1, total: 1000, self: 1000, mean: 1000},
+		{service: "api", caption: "get", count: 2, errors: 1, total: 200, self: 150, mean: 100},
+		{service: "db", caption: "get", count: 1, errors: 1, total: 50, self: 50, mean: 50},
+	}
+
+	if len(operations) != len(exp) {
+		t.Fatalf("got %d operations, expected %d", len(operations), len(exp))
+	}
+	for i, stats := range operations {
+		got := result{
+			service: stats.Service, caption: stats.Caption,
+			count: stats.Count, errors: stats.Errors,
+			total: stats.Total, self: stats.Self,
+			mean: stats.Mean,
+		}
+		if got != exp[i] {
+			t.Errorf("%d: got %+v, expected %+v", i, got, exp[i])
+		}
+		if len(stats.Spans) != stats.Count {
+			t.Errorf("%d: got %d spans, expected %d", i, len(stats.Spans), stats.Count)
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ten := []Time{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	hundred := make([]Time, 100)
+	for i := range hundred {
+		hundred[i] = Time(i + 1)
+	}
+
+	tests := []struct {
+		sorted []Time
+		p      int
+		exp    Time
+	}{
+		{sorted: []Time{7}, p: 50, exp: 7},
+		{sorted: []Time{7}, p: 99, exp: 7},
+		{sorted: ten, p: 0, exp: 1},
+		{sorted: ten, p: 50, exp: 5},
+		{sorted: ten, p: 51, exp: 6},
+		{sorted: ten, p: 95, exp: 10},
+		{sorted: ten, p: 100, exp: 10},
+		{sorted: hundred, p: 50, exp: 50},
+		{sorted: hundred, p: 95, exp: 95},
+		{sorted: hundred, p: 99, exp: 99},
+	}
+
+	for _, test := range tests {
+		if got := percentile(test.sorted, test.p); got != test.exp {
+			t.Errorf("p%d of %d values: got %d, expected %d", test.p, len(test.sorted), got, test.exp)
+		}
+	}
+}
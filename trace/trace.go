@@ -7,23 +7,30 @@ import (
 )
 
 type Timeline struct {
-	Traces   []*Trace
-	SpanByID map[TraceSpanID]*Span
+	Traces    []*Trace
+	SpanByID  map[TraceSpanID]*Span
+	Processes []*Process
 	TimeRange
 }
 
-type TraceID int64
-type SpanID int64
-
 type TraceSpanID struct {
 	TraceID TraceID
 	SpanID  SpanID
 }
 
-func (id TraceID) IsZero() bool     { return id == 0 }
-func (id SpanID) IsZero() bool      { return id == 0 }
 func (id TraceSpanID) IsZero() bool { return id == TraceSpanID{} }
 
+func (id TraceSpanID) Less(b TraceSpanID) bool {
+	if id.TraceID == b.TraceID {
+		return id.SpanID < b.SpanID
+	}
+	return id.TraceID.Less(b.TraceID)
+}
+
+func (id TraceSpanID) String() string {
+	return id.TraceID.String() + ":" + id.SpanID.String()
+}
+
 type Trace struct {
 	TraceID
 	TimeRange
@@ -34,7 +41,7 @@ type Trace struct {
 
 type Tag struct {
 	Key   string
-	Value string
+	Value Value
 }
 
 type Log struct {
@@ -48,6 +55,14 @@ type Span struct {
 	Caption string
 	TimeRange
 
+	Service string
+	Kind    SpanKind
+	Status  Status
+	Process *Process
+
+	// Skew is the clock skew adjustment applied to the span.
+	Skew Time
+
 	Parents  []*Span
 	Children []*Span
 
@@ -89,6 +104,11 @@ func (a TimeRange) Expand(b TimeRange) TimeRange {
 	}
 }
 
+// Sort sorts the traces and the spans by time and computes the render
+// order of each trace, where the children follow their parent.
+//
+// A span with multiple parents, e.g. after merging files that share
+// spans, is included only once, after the first parent that reaches it.
 func (timeline *Timeline) Sort() {
 	sort.Slice(timeline.Traces, func(i, k int) bool {
 		a := timeline.Traces[i]
@@ -114,9 +134,11 @@ func (timeline *Timeline) Sort() {
 		seen := make(map[*Span]struct{})
 		var include func(*Span)
 		include = func(span *Span) {
+			// Marking the span before the children also stops cycles.
 			if _, ok := seen[span]; ok {
 				return
 			}
+			seen[span] = struct{}{}
 			t.Order = append(t.Order, span)
 			for _, child := range span.Children {
 				include(child)
@@ -0,0 +1,27 @@
+package trace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortOrder(t *testing.T) {
+	shared := testSpan("shared", 30, 40)
+	a := link(testSpan("a", 0, 100), testSpan("a1", 10, 20), shared)
+	b := link(testSpan("b", 5, 50), shared)
+	// A cycle without a root is not reachable.
+	c1, c2 := testSpan("c1", 60, 70), testSpan("c2", 65, 68)
+	link(c1, c2)
+	link(c2, c1)
+
+	timeline := testTimeline(b, shared, a, a.Children[0], c1, c2)
+
+	var got []string
+	for _, span := range timeline.Traces[0].Order {
+		got = append(got, span.Caption)
+	}
+	exp := []string{"a", "a1", "shared", "b"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %q, expected %q", got, exp)
+	}
+}
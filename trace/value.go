@@ -0,0 +1,238 @@
+package trace
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ValueKind is the type of a Value.
+type ValueKind uint8
+
+const (
+	KindString ValueKind = iota
+	KindInt
+	KindFloat
+	KindBool
+	KindBytes
+	KindArray
+)
+
+// String returns the name of the kind.
+func (kind ValueKind) String() string {
+	switch kind {
+	case KindString:
+		return "string"
+	case KindInt:
+		return "int"
+	case KindFloat:
+		return "float"
+	case KindBool:
+		return "bool"
+	case KindBytes:
+		return "bytes"
+	case KindArray:
+		return "array"
+	default:
+		return "ValueKind(" + strconv.Itoa(int(kind)) + ")"
+	}
+}
+
+// Value is a typed tag value.
+//
+// The zero value is an empty string.
+type Value struct {
+	kind ValueKind
+	num  uint64  // int, float and bool
+	str  string  // string and bytes
+	arr  []Value // array
+}
+
+// StringValue returns a string value.
+func StringValue(v string) Value { return Value{kind: KindString, str: v} }
+
+// IntValue returns an int value.
+func IntValue(v int64) Value { return Value{kind: KindInt, num: uint64(v)} }
+
+// FloatValue returns a float value.
+func FloatValue(v float64) Value { return Value{kind: KindFloat, num: math.Float64bits(v)} }
+
+// BytesValue returns a bytes value, v is copied.
+func BytesValue(v []byte) Value { return Value{kind: KindBytes, str: string(v)} }
+
+// ArrayValue returns an array value of the elements in v.
+func ArrayValue(v ...Value) Value { return Value{kind: KindArray, arr: v} }
+
+// BoolValue returns a bool value.
+func BoolValue(v bool) Value {
+	if v {
+		return Value{kind: KindBool, num: 1}
+	}
+	return Value{kind: KindBool}
+}
+
+// AnyValue converts a dynamically typed value, such as one decoded
+// by encoding/json, to a Value. Unknown types are formatted as strings.
+func AnyValue(v any) Value {
+	switch v := v.(type) {
+	case Value:
+		return v
+	case string:
+		return StringValue(v)
+	case bool:
+		return BoolValue(v)
+	case int:
+		return IntValue(int64(v))
+	case int32:
+		return IntValue(int64(v))
+	case int64:
+		return IntValue(v)
+	case uint32:
+		return IntValue(int64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return FloatValue(float64(v))
+		}
+		return IntValue(int64(v))
+	case float32:
+		return FloatValue(float64(v))
+	case float64:
+		return FloatValue(v)
+	case []byte:
+		return BytesValue(v)
+	case []any:
+		values := make([]Value, len(v))
+		for i, x := range v {
+			values[i] = AnyValue(x)
+		}
+		return ArrayValue(values...)
+	case nil:
+		return StringValue("")
+	default:
+		return StringValue(fmt.Sprint(v))
+	}
+}
+
+func (v Value) Kind() ValueKind { return v.kind }
+
+// Int64 returns the value of an int, or a truncated float.
+func (v Value) Int64() int64 {
+	switch v.kind {
+	case KindInt:
+		return int64(v.num)
+	case KindFloat:
+		return int64(math.Float64frombits(v.num))
+	}
+	return 0
+}
+
+// Float64 returns the value of a float or an int.
+func (v Value) Float64() float64 {
+	switch v.kind {
+	case KindInt:
+		return float64(int64(v.num))
+	case KindFloat:
+		return math.Float64frombits(v.num)
+	}
+	return 0
+}
+
+func (v Value) Bool() bool { return v.kind == KindBool && v.num != 0 }
+
+func (v Value) Bytes() []byte {
+	if v.kind != KindBytes {
+		return nil
+	}
+	return []byte(v.str)
+}
+
+func (v Value) Array() []Value {
+	if v.kind != KindArray {
+		return nil
+	}
+	return v.arr
+}
+
+// IsNumber reports whether the value is an int or a float.
+func (v Value) IsNumber() bool { return v.kind == KindInt || v.kind == KindFloat }
+
+// String formats the value for display.
+func (v Value) String() string {
+	switch v.kind {
+	case KindString:
+		return v.str
+	case KindInt:
+		return strconv.FormatInt(int64(v.num), 10)
+	case KindFloat:
+		return strconv.FormatFloat(math.Float64frombits(v.num), 'g', -1, 64)
+	case KindBool:
+		return strconv.FormatBool(v.num != 0)
+	case KindBytes:
+		return "0x" + hex.EncodeToString([]byte(v.str))
+	case KindArray:
+		var b strings.Builder
+		b.WriteByte('[')
+		for i, x := range v.arr {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(x.String())
+		}
+		b.WriteByte(']')
+		return b.String()
+	}
+	return ""
+}
+
+// Equal reports whether the values are equal, ints and floats
+// with the same numeric value are considered equal.
+func (v Value) Equal(b Value) bool { return v.Compare(b) == 0 }
+
+// Compare compares the values using the real type, numbers are compared
+// numerically, booleans as false < true, arrays element-wise and other values
+// by their string representation. Values with different kinds compare
+// numbers first, then by their string representation.
+func (v Value) Compare(b Value) int {
+	switch {
+	case v.kind == KindInt && b.kind == KindInt:
+		return cmp.Compare(int64(v.num), int64(b.num))
+	case v.IsNumber() && b.IsNumber():
+		return cmp.Compare(v.Float64(), b.Float64())
+	case v.IsNumber():
+		return -1
+	case b.IsNumber():
+		return 1
+	case v.kind == KindBool && b.kind == KindBool:
+		return cmp.Compare(v.num, b.num)
+	case v.kind == KindBytes && b.kind == KindBytes:
+		return bytes.Compare([]byte(v.str), []byte(b.str))
+	case v.kind == KindArray && b.kind == KindArray:
+		for i := range min(len(v.arr), len(b.arr)) {
+			if r := v.arr[i].Compare(b.arr[i]); r != 0 {
+				return r
+			}
+		}
+		return cmp.Compare(len(v.arr), len(b.arr))
+	}
+	return strings.Compare(v.String(), b.String())
+}
+
+// SortedTags returns a copy of tags sorted by key and value,
+// leaving out the tags equal to the previous one.
+func SortedTags(tags []Tag) []Tag {
+	sorted := slices.Clone(tags)
+	slices.SortStableFunc(sorted, func(a, b Tag) int {
+		if c := strings.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return a.Value.Compare(b.Value)
+	})
+	return slices.CompactFunc(sorted, func(a, b Tag) bool {
+		return a.Key == b.Key && a.Value.Equal(b.Value)
+	})
+}
@@ -0,0 +1,32 @@
+package trace
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortedTags(t *testing.T) {
+	tags := []Tag{
+		{Key: "n", Value: IntValue(10)},
+		{Key: "b", Value: BoolValue(true)},
+		{Key: "n", Value: IntValue(9)},
+		{Key: "a", Value: StringValue("x")},
+		{Key: "n", Value: FloatValue(9)},
+		{Key: "b", Value: BoolValue(false)},
+	}
+
+	got := SortedTags(tags)
+	exp := []Tag{
+		{Key: "a", Value: StringValue("x")},
+		{Key: "b", Value: BoolValue(false)},
+		{Key: "b", Value: BoolValue(true)},
+		{Key: "n", Value: IntValue(9)},
+		{Key: "n", Value: IntValue(10)},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, expected %v", got, exp)
+	}
+	if tags[0].Key != "n" {
+		t.Fatalf("tags were modified")
+	}
+}
@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+type TextEditorStyle struct {
+	Editor material.EditorStyle
+}
+
+func TextEditor(theme *material.Theme, editor *widget.Editor, hint string) TextEditorStyle {
+	style := material.Editor(theme, editor, hint)
+	style.Color = color.NRGBA{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}
+	style.HintColor = color.NRGBA{R: 0x90, G: 0x90, B: 0x90, A: 0xFF}
+	style.TextSize = style.TextSize * 0.8
+	return TextEditorStyle{Editor: style}
+}
+
+func (edit TextEditorStyle) Layout(gtx layout.Context) layout.Dimensions {
+	gtx.Constraints.Min.X = gtx.Constraints.Max.X
+	return RoundBox(color.NRGBA{0x40, 0x40, 0x40, 0xFF}).Layout(gtx, edit.Editor.Layout)
+}
@@ -0,0 +1,26 @@
+package tui
+
+import (
+	"image/color"
+
+	"gioui.org/layout"
+	"gioui.org/widget"
+	"gioui.org/widget/material"
+)
+
+type ToggleStyle struct {
+	CheckBox material.CheckBoxStyle
+}
+
+func Toggle(theme *material.Theme, value *widget.Bool, caption string) ToggleStyle {
+	box := material.CheckBox(theme, value, caption)
+	box.Color = color.NRGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}
+	box.IconColor = color.NRGBA{R: 0xE0, G: 0xE0, B: 0xE0, A: 0xFF}
+	box.TextSize = box.TextSize * 0.8
+	box.Size = box.Size * 0.8
+	return ToggleStyle{CheckBox: box}
+}
+
+func (toggle ToggleStyle) Layout(gtx layout.Context) layout.Dimensions {
+	return toggle.CheckBox.Layout(gtx)
+}